@@ -43,6 +43,41 @@ func (anon *Anonymizer) AnonymizeMAC(mac net.HardwareAddr) {
 	}
 }
 
+// KeepsPrefix determines whether addresses within an IP prefix are identifiable after anonymization,
+// i.e. the prefix is no longer than the preserved leading bits or is entirely within keepIPs.
+// A nil Anonymizer keeps every prefix.
+func (anon *Anonymizer) KeepsPrefix(p netip.Prefix) bool {
+	if anon == nil {
+		return true
+	}
+	p = p.Masked()
+	bits := 48
+	if p.Addr().Is4() {
+		bits = 24
+	}
+	return p.Bits() <= bits || anon.keepIPs.ContainsPrefix(p)
+}
+
+// KeepsMAC determines whether MAC addresses are identifiable after anonymization.
+// A nil Anonymizer keeps MAC addresses.
+func (anon *Anonymizer) KeepsMAC() bool {
+	return anon == nil || anon.keepMAC
+}
+
+// Info describes the anonymization mode.
+func (anon *Anonymizer) Info() (info AnonymizerInfo) {
+	info.IPv4Bits, info.IPv6Bits, info.MACBits = 24, 48, 24
+	if anon.keepMAC {
+		info.MACBits = 48
+	}
+	if anon.keepIPs != nil {
+		for _, p := range anon.keepIPs.Prefixes() {
+			info.KeepIPs = append(info.KeepIPs, p.String())
+		}
+	}
+	return
+}
+
 // NewAnonymizer creates Anonymizer.
 func NewAnonymizer(keepIPs *netipx.IPSet, keepMAC bool, secret *[AnonymizerSecretLen]byte) (anon *Anonymizer) {
 	anon = &Anonymizer{
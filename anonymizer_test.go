@@ -54,4 +54,13 @@ func TestAnonymizer(t *testing.T) {
 	require.NoError(e)
 	anon.AnonymizeMAC(hwaddr)
 	assert.Equal("02:bf:8f:45:90:db", hwaddr.String())
+
+	assert.True(anon.KeepsPrefix(netip.MustParsePrefix("10.0.5.0/24")))
+	assert.False(anon.KeepsPrefix(netip.MustParsePrefix("10.0.5.2/32")))
+	assert.True(anon.KeepsPrefix(netip.MustParsePrefix("10.0.11.2/32")))
+	assert.True(anon.KeepsPrefix(netip.MustParsePrefix("fc9b:fd7b:5f42::/48")))
+	assert.False(anon.KeepsPrefix(netip.MustParsePrefix("fc9b:fd7b:5f42:47d0::/64")))
+	assert.True(anon.KeepsPrefix(netip.MustParsePrefix("fc44:966b:ce32:52c6::/64")))
+	assert.False(anon.KeepsMAC())
+	assert.True(ndntdump.NewAnonymizer(nil, true, nil).KeepsMAC())
 }
@@ -0,0 +1,48 @@
+package ndntdump
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// cborSelfDescribedTag is the CBOR self-described tag number.
+const cborSelfDescribedTag = 55799
+
+// CborMagic is the prefix of a CBOR records file.
+// It is the CBOR self-described tag, which encloses the header.
+var CborMagic = []byte{0xD9, 0xD9, 0xF7}
+
+var (
+	cborEnc, _ = cbor.EncOptions{
+		Time: cbor.TimeRFC3339Nano,
+	}.EncMode()
+	cborDec, _ = cbor.DecOptions{
+		DefaultMapType: reflect.TypeFor[map[string]any](),
+	}.DecMode()
+)
+
+// NewCborEncoder creates an encoder of CBOR records file.
+// Records and headers are encoded as a CBOR sequence of maps, keyed by JSON property keys.
+// Names are encoded as byte strings containing TLV-VALUE of the Name element.
+// The header should be encoded with CborHeader.
+func NewCborEncoder(w io.Writer) *cbor.Encoder {
+	return cborEnc.NewEncoder(w)
+}
+
+// CborHeader wraps a header in CBOR self-described tag, so that the file starts with CborMagic.
+func CborHeader(hdr Header) any {
+	return cbor.Tag{Number: cborSelfDescribedTag, Content: hdr}
+}
+
+func (d *RecordDecoder) initCbor(r io.Reader) {
+	dec := cborDec.NewDecoder(r)
+	d.unmarshal = cborDec.Unmarshal
+	d.read = func() ([]byte, error) {
+		// decode into a new RawMessage, because ndn.Name retains the input buffer
+		var item cbor.RawMessage
+		e := dec.Decode(&item)
+		return item, e
+	}
+}
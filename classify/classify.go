@@ -0,0 +1,49 @@
+// Package classify recognizes application protocols in NDN traffic.
+package classify
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndntdump"
+)
+
+var classifiers = map[string]ndntdump.Classifier{
+	ProtoNfd:  NfdMgmt,
+	ProtoNlsr: Nlsr,
+	"sync":    Sync,
+}
+
+// Names returns names of available classifiers.
+func Names() []string {
+	names := make([]string, 0, len(classifiers))
+	for name := range classifiers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ByName returns classifiers by name.
+// "all" selects every available classifier.
+func ByName(names []string) (list []ndntdump.Classifier, e error) {
+	for _, name := range names {
+		if name == "all" {
+			return ByName(Names())
+		}
+		c, ok := classifiers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown classifier %s, available: %s", name, strings.Join(Names(), " "))
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+// isKeyword determines whether a name component is a GenericNameComponent with specified value.
+func isKeyword(comp ndn.NameComponent, value string) bool {
+	return comp.Type == an.TtGenericNameComponent && string(comp.Value) == value
+}
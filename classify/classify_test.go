@@ -0,0 +1,203 @@
+package classify_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/classify"
+)
+
+func classifyInterest(interest ndn.Interest, classifiers ...ndntdump.Classifier) (rec ndntdump.Record) {
+	rec.SaveInterest(interest, an.NackNone)
+	for _, c := range classifiers {
+		if c(&rec, interest.ToPacket()) {
+			break
+		}
+	}
+	return
+}
+
+func classifyData(data ndn.Data, classifiers ...ndntdump.Classifier) (rec ndntdump.Record) {
+	rec.SaveData(data)
+	for _, c := range classifiers {
+		if c(&rec, data.ToPacket()) {
+			break
+		}
+	}
+	return
+}
+
+func TestByName(t *testing.T) {
+	assert := assert.New(t)
+
+	list, e := classify.ByName([]string{"nfd"})
+	assert.NoError(e)
+	assert.Len(list, 1)
+
+	list, e = classify.ByName([]string{"all"})
+	assert.NoError(e)
+	assert.Len(list, len(classify.Names()))
+
+	_, e = classify.ByName([]string{"nfd", "unknown"})
+	assert.Error(e)
+}
+
+func TestNfdMgmt(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	cp, _ := tlv.EncodeFrom(tlv.TLVFrom(ndntdump.TtControlParameters,
+		ndn.ParseName("/P"),
+		tlv.TLVNNI(0x6F, 65),      // Origin
+		tlv.TLVNNI(0x6A, 10),      // Cost
+		tlv.TLVNNI(0x6C, 1),       // Flags
+		tlv.TLVNNI(0x6D, 3600000), // ExpirationPeriod
+		tlv.TLVBytes(0x99, nil),   // unrecognized
+	))
+	name := ndn.ParseName("/localhop/nfd/rib/register").Append(ndn.MakeNameComponent(an.TtGenericNameComponent, cp))
+	rec := classifyInterest(ndn.MakeInterest(name), classify.NfdMgmt)
+	assert.Equal(classify.ProtoNfd, rec.Proto)
+	assert.Equal("rib/register", rec.ProtoOp)
+	require.NotNil(rec.ControlParams)
+	assert.Equal("/8=P", rec.ControlParams.Name.String())
+	assert.Equal(65, rec.ControlParams.Origin)
+	assert.Equal(10, rec.ControlParams.Cost)
+	assert.Equal(1, rec.ControlParams.Flags)
+	assert.Equal(3600000, rec.ControlParams.Expiration)
+
+	rec = classifyInterest(ndn.MakeInterest("/localhost/nfd/faces/list"), classify.NfdMgmt)
+	assert.Equal(classify.ProtoNfd, rec.Proto)
+	assert.Equal("faces/list", rec.ProtoOp)
+	assert.Nil(rec.ControlParams)
+
+	rec = classifyInterest(ndn.MakeInterest("/localhost/nfdx/faces/list"), classify.NfdMgmt)
+	assert.Empty(rec.Proto)
+}
+
+func TestNlsr(t *testing.T) {
+	assert := assert.New(t)
+
+	for name, op := range map[string]string{
+		"/ndn/site/%C1.Router/rtr1/nlsr/INFO/" + "%07%04%08%02AB": classify.NlsrHello,
+		"/localhop/ndn/nlsr/LSA/site/%C1.Router/rtr1/NAME/54=%01": classify.NlsrLsa,
+		"/localhop/ndn/nlsr/sync/%00":                             classify.NlsrSync,
+		"/ndn/nlsr":                                               "",
+		"/ndn/nlsr/other":                                         "",
+	} {
+		rec := classifyInterest(ndn.MakeInterest(name), classify.NfdMgmt, classify.Nlsr)
+		assert.Equal(op, rec.ProtoOp, name)
+		if op != "" {
+			assert.Equal(classify.ProtoNlsr, rec.Proto, name)
+		}
+	}
+}
+
+func TestSvs(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	sv2, _ := tlv.EncodeFrom(tlv.TLVFrom(ndntdump.TtStateVector,
+		tlv.TLVFrom(0xCA, ndn.ParseName("/A"), tlv.TLVNNI(0xCC, 5)),
+		tlv.TLVFrom(0xCA, ndn.ParseName("/B"), tlv.TLVNNI(0xCC, 7)),
+	))
+	interest := ndn.MakeInterest(ndn.ParseName("/G/54=%02"), sv2)
+	interest.UpdateParamsDigest()
+	rec := classifyInterest(interest, classify.Sync)
+	assert.Equal(classify.ProtoSvs, rec.Proto)
+	assert.Equal(classify.SyncOpSync, rec.ProtoOp)
+	assert.Equal("/8=G", rec.SyncGroup.String())
+	require.Len(rec.StateVector, 2)
+	assert.Equal("/8=A", rec.StateVector[0].Node.String())
+	assert.EqualValues(5, rec.StateVector[0].Seq)
+	assert.EqualValues(7, rec.StateVector[1].Seq)
+
+	sv3, _ := tlv.EncodeFrom(tlv.TLVFrom(ndntdump.TtStateVector,
+		tlv.TLVFrom(0xCA, ndn.ParseName("/A"),
+			tlv.TLVFrom(0xD2, tlv.TLVNNI(0xD4, 1700000000), tlv.TLVNNI(0xD6, 3)),
+			tlv.TLVFrom(0xD2, tlv.TLVNNI(0xD4, 1700000100), tlv.TLVNNI(0xD6, 1)),
+		),
+	))
+	data, _ := tlv.EncodeFrom(ndn.MakeData("/A", sv3))
+	interest = ndn.MakeInterest(ndn.ParseName("/G/32=svs/54=%03"), data)
+	interest.UpdateParamsDigest()
+	rec = classifyInterest(interest, classify.Sync)
+	assert.Equal(classify.ProtoSvs, rec.Proto)
+	assert.Equal("/8=G", rec.SyncGroup.String())
+	require.Len(rec.StateVector, 2)
+	assert.EqualValues(1700000000, rec.StateVector[0].Boot)
+	assert.EqualValues(3, rec.StateVector[0].Seq)
+	assert.EqualValues(1700000100, rec.StateVector[1].Boot)
+
+	rec = classifyInterest(ndn.MakeInterest(ndn.ParseName("/G/54=%02")), classify.Svs)
+	assert.Empty(rec.Proto)
+}
+
+func TestPSync(t *testing.T) {
+	assert := assert.New(t)
+
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write(make([]byte, 64))
+	w.Close()
+	ibfComp := ndn.MakeNameComponent(an.TtGenericNameComponent, compressed.Bytes())
+	ibf := ibfComp.String()
+	for name, op := range map[string]string{
+		"/G/hello":                           classify.SyncOpHello,
+		"/G/hello/" + ibf + "/54=%01/50=%00": classify.SyncOpHello,
+		"/G/sync/%01%02/" + ibf:              classify.SyncOpSync,
+		"/G/" + ibf:                          classify.SyncOpSync,
+		"/G/" + ibf + "/" + ibf + "/54=%01/50=%00": classify.SyncOpSync,
+		"/G/hello/world":     "",
+		"/G/%00%01%02":       "",
+		"/site/8080/index":   "",
+		"/G/%78%9C%01%02%03": "",
+	} {
+		rec := classifyInterest(ndn.MakeInterest(name, ndn.CanBePrefixFlag, ndn.MustBeFreshFlag), classify.PSync)
+		assert.Equal(op, rec.ProtoOp, name)
+		if op != "" {
+			assert.Equal(classify.ProtoPSync, rec.Proto, name)
+			assert.Equal("/8=G", rec.SyncGroup.String(), name)
+		}
+		if op == classify.SyncOpSync {
+			assert.Equal(compressed.Len(), rec.IBFSize, name)
+		}
+	}
+
+	// hello without IBF requires Interest with CanBePrefix and MustBeFresh
+	assert.Empty(classifyInterest(ndn.MakeInterest("/G/hello"), classify.PSync).Proto)
+	assert.Empty(classifyData(ndn.MakeData("/G/hello"), classify.PSync).Proto)
+}
+
+func TestChronoSync(t *testing.T) {
+	assert := assert.New(t)
+
+	digest := ndn.MakeNameComponent(an.TtGenericNameComponent, make([]byte, 32))
+	for name, op := range map[string]string{
+		ndn.ParseName("/G").Append(digest).String():          classify.SyncOpSync,
+		ndn.ParseName("/G/recovery").Append(digest).String(): classify.SyncOpRecovery,
+		"/G/reset": classify.SyncOpReset,
+		"/G/other": "",
+	} {
+		rec := classifyInterest(ndn.MakeInterest(name, ndn.MustBeFreshFlag), classify.ChronoSync)
+		assert.Equal(op, rec.ProtoOp, name)
+		if op != "" {
+			assert.Equal(classify.ProtoChronoSync, rec.Proto, name)
+			assert.Equal("/8=G", rec.SyncGroup.String(), name)
+		}
+	}
+
+	name := ndn.ParseName("/G").Append(digest)
+	assert.Empty(classifyInterest(ndn.MakeInterest(name), classify.ChronoSync).Proto)
+	assert.Empty(classifyInterest(ndn.MakeInterest(name, ndn.MustBeFreshFlag, ndn.CanBePrefixFlag), classify.ChronoSync).Proto)
+
+	reply, _ := tlv.EncodeFrom(tlv.TLV(128, tlv.TLV(129)))
+	rec := classifyData(ndn.MakeData(name, reply), classify.ChronoSync)
+	assert.Equal(classify.ProtoChronoSync, rec.Proto)
+	assert.Equal(classify.SyncOpSync, rec.ProtoOp)
+	assert.Empty(classifyData(ndn.MakeData(name, []byte{0xC0, 0xC1}), classify.ChronoSync).Proto)
+}
@@ -0,0 +1,46 @@
+package classify
+
+import (
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
+	"github.com/usnistgov/ndntdump"
+)
+
+// ProtoNfd is the Proto value of NFD management traffic.
+const ProtoNfd = "nfd"
+
+var (
+	prefixNfdLocalhost = ndn.ParseName("/localhost/nfd")
+	prefixNfdLocalhop  = ndn.ParseName("/localhop/nfd")
+)
+
+// NfdMgmt recognizes NFD management commands and status datasets under /localhost/nfd and /localhop/nfd prefixes.
+// ProtoOp is module and verb, such as "rib/register" or "faces/list".
+// ControlParameters in command Interests are decoded.
+func NfdMgmt(rec *ndntdump.Record, pkt *ndn.Packet) bool {
+	name := rec.Name
+	if !prefixNfdLocalhost.IsPrefixOf(name) && !prefixNfdLocalhop.IsPrefixOf(name) {
+		return false
+	}
+	rec.Proto = ProtoNfd
+
+	rest := name[len(prefixNfdLocalhost):]
+	for i, comp := range rest[:min(2, len(rest))] {
+		if comp.Type != an.TtGenericNameComponent {
+			break
+		}
+		if i > 0 {
+			rec.ProtoOp += "/"
+		}
+		rec.ProtoOp += string(comp.Value)
+	}
+
+	if len(rest) > 2 && rest[2].Type == an.TtGenericNameComponent {
+		var cp ndntdump.ControlParameters
+		if tlv.Decode(rest[2].Value, &cp) == nil {
+			rec.ControlParams = &cp
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package classify
+
+import (
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndntdump"
+)
+
+// ProtoNlsr is the Proto value of NLSR traffic.
+const ProtoNlsr = "nlsr"
+
+// NLSR ProtoOp values.
+const (
+	NlsrHello = "hello"
+	NlsrLsa   = "lsa"
+	NlsrSync  = "sync"
+)
+
+var nlsrOps = map[string]string{
+	"INFO": NlsrHello,
+	"LSA":  NlsrLsa,
+	"sync": NlsrSync,
+}
+
+// Nlsr recognizes NLSR traffic by its naming conventions:
+//   - hello: /<router>/nlsr/INFO/<neighbor>
+//   - lsa: /localhop/<network>/nlsr/LSA/<router>/<type>/<seq>
+//   - sync: /localhop/<network>/nlsr/sync/...
+func Nlsr(rec *ndntdump.Record, pkt *ndn.Packet) bool {
+	name := rec.Name
+	for i := range max(len(name)-1, 0) {
+		if !isKeyword(name[i], "nlsr") {
+			continue
+		}
+		if op, ok := nlsrOps[string(name[i+1].Value)]; ok && name[i+1].Type == an.TtGenericNameComponent {
+			rec.Proto, rec.ProtoOp = ProtoNlsr, op
+			return true
+		}
+	}
+	return false
+}
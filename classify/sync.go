@@ -0,0 +1,195 @@
+package classify
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
+	"github.com/usnistgov/ndntdump"
+)
+
+// Proto values of sync protocols.
+const (
+	ProtoSvs        = "svs"
+	ProtoPSync      = "psync"
+	ProtoChronoSync = "chronosync"
+)
+
+// Sync protocol ProtoOp values.
+const (
+	SyncOpSync     = "sync"
+	SyncOpHello    = "hello"
+	SyncOpRecovery = "recovery"
+	SyncOpReset    = "reset"
+)
+
+// chronoSyncDigestLen is the length of ChronoSync state digest component.
+const chronoSyncDigestLen = 32
+
+// chronoSyncTtSyncReply is the TLV-TYPE of ChronoSync SyncReply, carried in Data Content.
+const chronoSyncTtSyncReply = 128
+
+// Sync recognizes sync protocol traffic, trying Svs, PSync, and ChronoSync in order.
+func Sync(rec *ndntdump.Record, pkt *ndn.Packet) bool {
+	return Svs(rec, pkt) || PSync(rec, pkt) || ChronoSync(rec, pkt)
+}
+
+// Svs recognizes State Vector Sync traffic.
+// A sync Interest either carries a StateVector in ApplicationParameters (directly in SVS v2,
+// or in the Content of an encapsulated Data in SVS v3), or has a name containing a "32=svs" keyword
+// or ending with a StateVector component (SVS v1).
+// SyncGroup is the name with StateVector, version, and "32=svs" components removed.
+// The StateVector is decoded and saved.
+func Svs(rec *ndntdump.Record, pkt *ndn.Packet) bool {
+	name := rec.Name
+	if len(name) > 0 && name[len(name)-1].Type == an.TtParametersSha256DigestComponent {
+		name = name[:len(name)-1]
+	}
+
+	var sv ndntdump.StateVector
+	found := false
+	if pkt.Interest != nil && len(pkt.Interest.AppParameters) > 0 {
+		found = decodeStateVector(bytes.Clone(pkt.Interest.AppParameters), &sv)
+	}
+	if len(name) > 0 && name[len(name)-1].Type == an.TtGenericNameComponent &&
+		tlv.Decode(bytes.Clone(name[len(name)-1].Value), &sv) == nil {
+		found, name = true, name[:len(name)-1]
+	}
+	if len(name) > 0 && name[len(name)-1].Type == an.TtVersionNameComponent {
+		name = name[:len(name)-1]
+	}
+	if len(name) > 0 && name[len(name)-1].Type == an.TtKeywordNameComponent && string(name[len(name)-1].Value) == ProtoSvs {
+		found, name = true, name[:len(name)-1]
+	}
+	if !found {
+		return false
+	}
+
+	rec.Proto, rec.ProtoOp, rec.SyncGroup = ProtoSvs, SyncOpSync, name
+	if len(sv) > 0 {
+		rec.StateVector = sv
+	}
+	return true
+}
+
+// decodeStateVector finds and decodes a StateVector in ApplicationParameters.
+func decodeStateVector(wire []byte, sv *ndntdump.StateVector) bool {
+	d := tlv.DecodingBuffer(wire)
+	for de := range d.IterElements() {
+		switch de.Type {
+		case ndntdump.TtStateVector:
+			return de.Unmarshal(sv) == nil
+		case an.TtData:
+			d1 := tlv.DecodingBuffer(de.Value)
+			for de1 := range d1.IterElements() {
+				if de1.Type == an.TtContent {
+					return tlv.Decode(de1.Value, sv) == nil
+				}
+			}
+		}
+	}
+	return false
+}
+
+// PSync recognizes PSync traffic by its naming conventions:
+//   - partial sync hello: /<group>/hello[/<IBF>/...], where a hello Interest has CanBePrefix and MustBeFresh
+//   - partial sync: /<group>/sync/<BF>/<IBF>[/...]
+//   - full sync: /<group>/<IBF>[/...]
+//
+// An IBF component is identified as a GenericNameComponent that decompresses as a zlib stream.
+// IBFSize is the length of the IBF component.
+func PSync(rec *ndntdump.Record, pkt *ndn.Packet) bool {
+	name := rec.Name
+	for i, comp := range name {
+		if i == 0 {
+			continue
+		}
+		var op string
+		ibf := -1
+		switch {
+		case isKeyword(comp, "hello") && i == len(name)-1:
+			if pkt.Data != nil || !rec.CanBePrefix || !rec.MustBeFresh {
+				continue
+			}
+			op = SyncOpHello
+		case isKeyword(comp, "hello") && isIBF(name[i+1]):
+			op, ibf = SyncOpHello, i+1
+		case isKeyword(comp, "sync") && i+2 < len(name) && isIBF(name[i+2]):
+			op, ibf = SyncOpSync, i+2
+		case isIBF(comp):
+			op, ibf = SyncOpSync, i
+		default:
+			continue
+		}
+
+		rec.Proto, rec.ProtoOp, rec.SyncGroup = ProtoPSync, op, name.GetPrefix(i)
+		if ibf >= 0 {
+			rec.IBFSize = len(name[ibf].Value)
+		}
+		return true
+	}
+	return false
+}
+
+// maxIBFSize is the maximum decompressed size of a PSync IBF.
+const maxIBFSize = 1 << 20
+
+// isIBF determines whether a name component looks like a PSync IBF,
+// which is a complete zlib stream with valid checksum.
+func isIBF(comp ndn.NameComponent) bool {
+	v := comp.Value
+	if comp.Type != an.TtGenericNameComponent || len(v) < 2 ||
+		v[0]&0x0F != 8 || v[0]>>4 > 7 || (uint16(v[0])<<8|uint16(v[1]))%31 != 0 {
+		return false
+	}
+
+	br := bytes.NewReader(v)
+	r, e := zlib.NewReader(br)
+	if e != nil {
+		return false
+	}
+	n, e := io.Copy(io.Discard, io.LimitReader(r, maxIBFSize))
+	return e == nil && n < maxIBFSize && br.Len() == 0
+}
+
+// ChronoSync recognizes ChronoSync traffic by its naming conventions:
+//   - sync: /<group>/<digest>
+//   - recovery: /<group>/recovery/<digest>
+//   - reset: /<group>/reset
+//
+// A digest component is identified as a 32-octet GenericNameComponent.
+// An Interest must have MustBeFresh, and must not have CanBePrefix or ApplicationParameters.
+// A Data must carry a SyncReply in its Content.
+func ChronoSync(rec *ndntdump.Record, pkt *ndn.Packet) bool {
+	name := rec.Name
+	if len(name) < 2 {
+		return false
+	}
+	if pkt.Data != nil {
+		var typ tlv.VarNum
+		if _, e := typ.Decode(pkt.Data.Content); e != nil || typ != chronoSyncTtSyncReply {
+			return false
+		}
+	} else if !rec.MustBeFresh || rec.CanBePrefix || rec.AppParams {
+		return false
+	}
+
+	last := name[len(name)-1]
+	group, op := name[:len(name)-1], SyncOpSync
+	switch {
+	case isKeyword(last, "reset"):
+		op = SyncOpReset
+	case last.Type == an.TtGenericNameComponent && len(last.Value) == chronoSyncDigestLen:
+		if len(group) > 1 && isKeyword(group[len(group)-1], "recovery") {
+			group, op = group[:len(group)-1], SyncOpRecovery
+		}
+	default:
+		return false
+	}
+
+	rec.Proto, rec.ProtoOp, rec.SyncGroup = ProtoChronoSync, op, group
+	return true
+}
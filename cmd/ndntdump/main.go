@@ -3,29 +3,327 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"slices"
+	"strings"
 	"syscall"
 
+	"github.com/dustin/go-humanize"
 	"github.com/urfave/cli/v2"
 	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/classify"
 	"github.com/usnistgov/ndntdump/fileoutput"
+	"github.com/usnistgov/ndntdump/filter"
+	"github.com/usnistgov/ndntdump/livefeed"
 	"github.com/usnistgov/ndntdump/pcapinput"
-	"go4.org/netipx"
+	"github.com/usnistgov/ndntdump/sample"
 )
 
 var (
-	keepIPs *netipx.IPSet
-	input   pcapinput.Handle
-	reader  *ndntdump.Reader
-	output  ndntdump.RecordOutput
+	input  pcapinput.Handle
+	reader *ndntdump.Reader
+	output ndntdump.RecordOutput
 )
 
+// readerFlags are shared between the main command and subcommands.
+var readerFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:  "local",
+		Usage: "local MAC `address`, or ifname=address for a pcapng interface (repeatable)",
+	},
+	&cli.IntFlag{
+		Name:  "tcp-port",
+		Usage: "NDN over TCP `port`",
+		Value: 6363,
+	},
+	&cli.IntFlag{
+		Name:  "wss-port",
+		Usage: "WebSocket server `port`",
+		Value: 9696,
+	},
+	&cli.StringSliceFlag{
+		Name:    "keep-ip",
+		Aliases: []string{"N"},
+		Usage:   "don't anonymize IP `prefix`",
+	},
+	&cli.BoolFlag{
+		Name:  "keep-mac",
+		Usage: "don't anonymize MAC addresses",
+	},
+	&cli.BoolFlag{
+		Name:  "keep-payload",
+		Usage: "don't zeroize payload",
+	},
+	&cli.BoolFlag{
+		Name:  "name-comps",
+		Usage: "save structured name components in records",
+	},
+	&cli.IntFlag{
+		Name:  "name-prefix",
+		Usage: "save name prefix truncated to `n` components in records",
+	},
+	&cli.BoolFlag{
+		Name:  "flow-info",
+		Usage: "save structured flow key and flow ID in records",
+	},
+	&cli.StringSliceFlag{
+		Name:  "classify",
+		Usage: "recognize application `protocol` (" + strings.Join(classify.Names(), ", ") + ", all) (repeatable)",
+	},
+	&cli.BoolFlag{
+		Name:  "diag",
+		Usage: "write diagnostic records for dropped frames, and print counters upon exit",
+	},
+	&cli.BoolFlag{
+		Name:  "diag-not-ndn",
+		Usage: "with --diag, also write diagnostic records for frames that do not carry NDN traffic",
+	},
+}
+
+// outputFlags are shared between the main command and subcommands.
+var outputFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "records-format",
+		Usage: "records output `format` (" + strings.Join([]string{fileoutput.FormatNdjson, fileoutput.FormatCbor, fileoutput.FormatParquet, fileoutput.FormatCsv, fileoutput.FormatTsv, fileoutput.FormatSqlite}, ", ") + "), default is determined from filename extension",
+	},
+	&cli.StringFlag{
+		Name:  "parquet-compression",
+		Usage: "Parquet compression `codec` (" + strings.Join(fileoutput.ParquetCodecs(), ", ") + ")",
+		Value: "zstd",
+	},
+	&cli.IntFlag{
+		Name:  "parquet-row-group",
+		Usage: "maximum `rows` in a Parquet row group",
+		Value: 1 << 20,
+	},
+	&cli.StringFlag{
+		Name:  "parquet-row-group-size",
+		Usage: "maximum estimated uncompressed `size` of a Parquet row group (e.g. 64MB)",
+	},
+	&cli.DurationFlag{
+		Name:  "parquet-flush",
+		Usage: "maximum `duration` between Parquet row group flushes",
+	},
+	&cli.StringSliceFlag{
+		Name:  "csv-columns",
+		Usage: "CSV/TSV `columns`, named after records JSON property keys (default: " + strings.Join(fileoutput.DefaultCsvColumns, ",") + ")",
+	},
+	&cli.IntFlag{
+		Name:  "sqlite-batch",
+		Usage: "number of `records` in each SQLite transaction",
+		Value: 10000,
+	},
+	&cli.StringFlag{
+		Name:  "records-filter",
+		Usage: "write only records matching filter `expression`",
+	},
+	&cli.StringFlag{
+		Name:  "pcapng-filter",
+		Usage: "write only packets matching filter `expression` to pcapng",
+	},
+	&cli.BoolFlag{
+		Name:  "pcapng-snap",
+		Usage: "truncate packets in pcapng after NDN name and metadata",
+	},
+	&cli.StringFlag{
+		Name:  "sample",
+		Usage: "write a sample of packets, as `mode:rate` where mode is " + strings.Join(sample.Modes(), ", ") + " and rate is 0.01 or 1/100",
+	},
+	&cli.IntFlag{
+		Name:  "sample-prefix-len",
+		Usage: "number of name `components` hashed in prefix sampling mode",
+		Value: 1,
+	},
+	&cli.Uint64Flag{
+		Name:  "sample-seed",
+		Usage: "hash `seed` in flow and prefix sampling modes, or random seed in random sampling mode",
+	},
+}
+
+func newAnonymizer(c *cli.Context) (*ndntdump.Anonymizer, error) {
+	keepIPs, e := ndntdump.ParseIPSet(c.StringSlice("keep-ip"))
+	if e != nil {
+		return nil, e
+	}
+	return ndntdump.NewAnonymizer(keepIPs, c.Bool("keep-mac"), nil), nil
+}
+
+func newReader(c *cli.Context, input pcapinput.Handle, anon *ndntdump.Anonymizer) (*ndntdump.Reader, error) {
+	classifiers, e := classify.ByName(c.StringSlice("classify"))
+	if e != nil {
+		return nil, e
+	}
+	return ndntdump.NewReader(input, ndntdump.ReaderOptions{
+		IsLocal:           input.IsLocal,
+		LinkType:          input.LinkType,
+		FaceID:            input.FaceID,
+		TCPPort:           c.Int("tcp-port"),
+		WebSocketPort:     c.Int("wss-port"),
+		Anonymizer:        anon,
+		KeepPayload:       c.Bool("keep-payload"),
+		NameComponents:    c.Bool("name-comps"),
+		NamePrefixLen:     c.Int("name-prefix"),
+		Diagnostics:       c.Bool("diag"),
+		DiagnosticsNotNDN: c.Bool("diag-not-ndn"),
+		FlowInfo:          c.Bool("flow-info"),
+		Classifiers:       classifiers,
+	}), nil
+}
+
+// redactedFlags are flags whose values are not recorded in the records file header.
+// They may contain real addresses, which would defeat anonymization, or local paths and commands.
+// keep-ip is not redacted, because its prefixes are recorded in the anonymizer info and appear unanonymized in records.
+var redactedFlags = map[string]bool{
+	"local":          true,
+	"ifname":         true,
+	"input":          true,
+	"records-filter": true,
+	"pcapng-filter":  true,
+	"rotate-hook":    true,
+	"input-dir":      true,
+	"output-dir":     true,
+	"done-dir":       true,
+	"failed-dir":     true,
+	"journal":        true,
+}
+
+// redactedValue replaces the value of a redacted flag.
+const redactedValue = "(redacted)"
+
+// newHeader creates a records file header that describes this invocation.
+// Values of redactedFlags are replaced, so that the header reveals only that they are set.
+func newHeader(c *cli.Context, flags []cli.Flag, anon *ndntdump.Anonymizer) ndntdump.Header {
+	hdr := ndntdump.Header{
+		Tool:        c.App.Name,
+		Options:     map[string]any{},
+		Anon:        anon.Info(),
+		KeepPayload: c.Bool("keep-payload"),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		hdr.Tool += " " + bi.Main.Version
+	}
+	for _, f := range flags {
+		name := f.Names()[0]
+		if !c.IsSet(name) {
+			continue
+		}
+		if redactedFlags[name] {
+			hdr.Options[name] = redactedValue
+			continue
+		}
+		value := c.Value(name)
+		if ss, ok := value.(cli.StringSlice); ok {
+			value = ss.Value()
+		}
+		hdr.Options[name] = value
+	}
+	return hdr
+}
+
+// newOutputOptions creates output options that describe this invocation.
+func newOutputOptions(c *cli.Context, flags []cli.Flag, anon *ndntdump.Anonymizer) (opts fileoutput.Options, e error) {
+	opts = fileoutput.Options{
+		Header:        newHeader(c, flags, anon),
+		RecordsFormat: c.String("records-format"),
+		Parquet: fileoutput.ParquetOptions{
+			Compression:      c.String("parquet-compression"),
+			RowGroupRows:     c.Int("parquet-row-group"),
+			RowGroupInterval: c.Duration("parquet-flush"),
+		},
+		Csv: fileoutput.CsvOptions{
+			Columns: c.StringSlice("csv-columns"),
+		},
+		Sqlite: fileoutput.SqliteOptions{
+			BatchSize: c.Int("sqlite-batch"),
+		},
+		Pcapng: fileoutput.PcapngOptions{
+			Snap: c.Bool("pcapng-snap"),
+		},
+	}
+	if size := c.String("parquet-row-group-size"); size != "" {
+		n, e := humanize.ParseBytes(size)
+		if e != nil {
+			return opts, fmt.Errorf("--parquet-row-group-size: %w", e)
+		}
+		opts.Parquet.RowGroupBytes = int64(n)
+	}
+	if opts.RecordsFilter, e = filter.ParseAnonymized(c.String("records-filter"), anon); e != nil {
+		return opts, fmt.Errorf("--records-filter: %w", e)
+	}
+	if opts.PcapngFilter, e = filter.ParseAnonymized(c.String("pcapng-filter"), anon); e != nil {
+		return opts, fmt.Errorf("--pcapng-filter: %w", e)
+	}
+	if c.IsSet("sample") {
+		sampleOpts, e := sample.ParseOptions(c.String("sample"))
+		if e != nil {
+			return opts, fmt.Errorf("--sample: %w", e)
+		}
+		sampleOpts.PrefixLen, sampleOpts.Seed = c.Int("sample-prefix-len"), c.Uint64("sample-seed")
+		if opts.Sampler, e = sample.New(sampleOpts); e != nil {
+			return opts, fmt.Errorf("--sample: %w", e)
+		}
+	}
+	return opts, nil
+}
+
+// newRotateOptions creates automatic rotation options.
+func newRotateOptions(c *cli.Context) (opts fileoutput.RotateOptions, e error) {
+	opts = fileoutput.RotateOptions{
+		Interval:   c.Duration("rotate-interval"),
+		MaxPackets: c.Int("rotate-packets"),
+		MaxFiles:   c.Int("rotate-keep"),
+		Hook:       strings.Fields(c.String("rotate-hook")),
+	}
+	if size := c.String("rotate-size"); size != "" {
+		n, e := humanize.ParseBytes(size)
+		if e != nil {
+			return opts, fmt.Errorf("--rotate-size: %w", e)
+		}
+		opts.MaxSize = int64(n)
+	}
+	return opts, nil
+}
+
+// printDiagCounters prints diagnostic counters to stderr.
+func printDiagCounters(c *cli.Context, label string, reader *ndntdump.Reader) {
+	if !c.Bool("diag") {
+		return
+	}
+	counters := reader.DiagCounters()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: diagnostics", label)
+	for _, reason := range slices.Sorted(maps.Keys(counters)) {
+		fmt.Fprintf(&b, " %s=%d", reason, counters[reason])
+	}
+	fmt.Fprintln(c.App.ErrWriter, b.String())
+}
+
+// copyRecords reads records until EOF and writes them to output.
+func copyRecords(reader *ndntdump.Reader, output ndntdump.RecordOutput) error {
+	for {
+		rec, e := reader.Read()
+		if e != nil {
+			if errors.Is(e, io.EOF) {
+				return nil
+			}
+			return e
+		}
+
+		if e = output.Write(rec); e != nil {
+			return e
+		}
+	}
+}
+
 var app = &cli.App{
 	Name:  "ndntdump",
 	Usage: "capture, anonymize, and analyze NDN traffic",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "ifname",
 			Aliases: []string{"i"},
@@ -34,64 +332,102 @@ var app = &cli.App{
 		&cli.StringFlag{
 			Name:    "input",
 			Aliases: []string{"r"},
-			Usage:   "input `filename`",
+			Usage:   "input `filename`, or - for stdin",
 		},
-		&cli.StringFlag{
-			Name:  "local",
-			Usage: "local MAC `address`",
-		},
-		&cli.IntFlag{
-			Name:  "tcp-port",
-			Usage: "NDN over TCP `port`",
-			Value: 6363,
-		},
-		&cli.IntFlag{
-			Name:  "wss-port",
-			Usage: "WebSocket server `port`",
-			Value: 9696,
+		&cli.BoolFlag{
+			Name:  "follow",
+			Usage: "keep reading input file as it grows, and continue with rotated files",
 		},
 		&cli.StringFlag{
 			Name:    "pcapng",
 			Aliases: []string{"w"},
-			Usage:   ".pcapng.gz output `filename`",
+			Usage:   ".pcapng.gz output `filename`, - for stdout, or unix:path or tcp:host:port listener",
 		},
 		&cli.StringFlag{
 			Name:    "json",
 			Aliases: []string{"L"},
-			Usage:   ".json.gz output `filename`",
+			Usage:   ".json.gz, .cbor.gz, .parquet, .csv.gz, or .sqlite records output `filename`, - for stdout, or unix:path or tcp:host:port listener",
+		},
+		&cli.StringFlag{
+			Name:  "live",
+			Usage: "serve live records over WebSocket and Server-Sent Events on `host:port`",
 		},
 		&cli.StringSliceFlag{
-			Name:    "keep-ip",
-			Aliases: []string{"N"},
-			Usage:   "don't anonymize IP `prefix`",
+			Name:  "live-origin",
+			Usage: "allow browser pages from web `origin` (e.g. https://dashboard.example.net) to subscribe to live records",
 		},
-		&cli.BoolFlag{
-			Name:  "keep-mac",
-			Usage: "don't anonymize MAC addresses",
+		&cli.IntFlag{
+			Name:  "stream-buffer",
+			Usage: "maximum `records` queued for each listener or live subscriber, beyond which records are dropped",
+			Value: 1024,
 		},
-		&cli.BoolFlag{
-			Name:  "keep-payload",
-			Usage: "don't zeroize payload",
+		&cli.DurationFlag{
+			Name:  "rotate-interval",
+			Usage: "rotate output files at wall-clock boundaries of `interval`",
+		},
+		&cli.StringFlag{
+			Name:  "rotate-size",
+			Usage: "rotate output files after reaching `size` (e.g. 500MB)",
+		},
+		&cli.IntFlag{
+			Name:  "rotate-packets",
+			Usage: "rotate output files after `n` records",
 		},
+		&cli.IntFlag{
+			Name:  "rotate-keep",
+			Usage: "keep at most `n` files of each output, deleting older files",
+		},
+		&cli.StringFlag{
+			Name:  "rotate-hook",
+			Usage: "run `command` after each output file is closed, with filename as last argument",
+		},
+	}, slices.Concat(readerFlags, outputFlags)...),
+	Commands: []*cli.Command{
+		spoolCommand,
 	},
 	Action: func(c *cli.Context) (e error) {
-		if input, e = pcapinput.Open(c.String("ifname"), c.String("input"), c.String("local")); e != nil {
+		if c.Bool("follow") {
+			if c.IsSet("ifname") {
+				return cli.Exit("--follow cannot be used with --ifname", 1)
+			}
+			input, e = pcapinput.OpenFollow(c.String("input"), c.StringSlice("local"))
+		} else {
+			input, e = pcapinput.Open(c.String("ifname"), c.String("input"), c.StringSlice("local"))
+		}
+		if e != nil {
 			return cli.Exit(e, 1)
 		}
-		if keepIPs, e = ndntdump.ParseIPSet(c.StringSlice("keep-ip")); e != nil {
+		anon, e := newAnonymizer(c)
+		if e != nil {
+			return cli.Exit(e, 1)
+		}
+		if reader, e = newReader(c, input, anon); e != nil {
 			return cli.Exit(e, 1)
 		}
-		reader = ndntdump.NewReader(input, ndntdump.ReaderOptions{
-			IsLocal:       input.IsLocal,
-			TCPPort:       c.Int("tcp-port"),
-			WebSocketPort: c.Int("wss-port"),
-			Anonymizer:    ndntdump.NewAnonymizer(keepIPs, c.Bool("keep-mac"), nil),
-			KeepPayload:   c.Bool("keep-payload"),
-		})
 
-		if output, e = fileoutput.Open(c.String("json"), c.String("pcapng")); e != nil {
+		outputOpts, e := newOutputOptions(c, c.App.Flags, anon)
+		if e != nil {
+			return cli.Exit(e, 1)
+		}
+		if outputOpts.Rotate, e = newRotateOptions(c); e != nil {
 			return cli.Exit(e, 1)
 		}
+		outputOpts.Stream.Buffer = c.Int("stream-buffer")
+		if output, e = fileoutput.Open(c.String("json"), c.String("pcapng"), outputOpts); e != nil {
+			return cli.Exit(e, 1)
+		}
+		if addr := c.String("live"); addr != "" {
+			live, e := livefeed.NewServer(addr, livefeed.Options{
+				Header:       outputOpts.Header,
+				Buffer:       outputOpts.Stream.Buffer,
+				AllowOrigins: c.StringSlice("live-origin"),
+			})
+			if e != nil {
+				output.Close()
+				return cli.Exit(e, 1)
+			}
+			output = fileoutput.Join(output, live)
+		}
 		defer output.Close()
 
 		sig := make(chan os.Signal, 1)
@@ -102,20 +438,12 @@ var app = &cli.App{
 			input.Close()
 		}()
 
-		for {
-			rec, e := reader.Read()
-			if e != nil {
-				if errors.Is(e, io.EOF) {
-					return nil
-				}
-				return cli.Exit(e, 1)
-			}
-
-			e = output.Write(rec)
-			if e != nil {
-				return cli.Exit(e, 1)
-			}
+		e = copyRecords(reader, output)
+		printDiagCounters(c, c.App.Name, reader)
+		if e != nil {
+			return cli.Exit(e, 1)
 		}
+		return nil
 	},
 	After: func(c *cli.Context) error {
 		if input != nil {
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+	"github.com/usnistgov/ndntdump"
+)
+
+func TestHeaderRedaction(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	var hdr ndntdump.Header
+	a := &cli.App{
+		Name:  "ndntdump",
+		Flags: app.Flags,
+		Action: func(c *cli.Context) error {
+			anon, e := newAnonymizer(c)
+			if e != nil {
+				return e
+			}
+			hdr = newHeader(c, c.App.Flags, anon)
+			return nil
+		},
+	}
+	require.NoError(a.Run([]string{"ndntdump",
+		"--local", "02:00:00:00:00:01", "--local", "eth1=02:00:00:00:00:02",
+		"--input", "/data/site1.pcapng",
+		"--keep-ip", "192.0.2.0/24",
+		"--records-filter", "host 198.51.100.7",
+		"--name-prefix", "2",
+	}))
+
+	j, e := json.Marshal(hdr)
+	require.NoError(e)
+	for _, secret := range []string{"02:00:00:00:00:01", "02:00:00:00:00:02", "/data/site1.pcapng", "198.51.100.7"} {
+		assert.NotContains(string(j), secret)
+	}
+	assert.Equal(redactedValue, hdr.Options["local"])
+	assert.Equal(redactedValue, hdr.Options["input"])
+	assert.Equal([]string{"192.0.2.0/24"}, hdr.Options["keep-ip"])
+	assert.Equal([]string{"192.0.2.0/24"}, hdr.Anon.KeepIPs)
+	assert.Equal(2, hdr.Options["name-prefix"])
+}
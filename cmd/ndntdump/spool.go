@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/classify"
+	"github.com/usnistgov/ndntdump/fileoutput"
+	"github.com/usnistgov/ndntdump/pcapinput"
+	"github.com/usnistgov/ndntdump/spool"
+)
+
+// traceBaseName strips trace file extensions from a filename.
+func traceBaseName(name string) string {
+	for {
+		switch ext := filepath.Ext(name); ext {
+		case ".gz", ".zst", ".pcap", ".pcapng", ".cap":
+			name = strings.TrimSuffix(name, ext)
+		default:
+			return name
+		}
+	}
+}
+
+// spoolOutput contains output filenames of an input file.
+// Files are written under temporary names and renamed upon success.
+type spoolOutput struct {
+	tmp   [2]string
+	final [2]string
+}
+
+func (so *spoolOutput) init(dir, base string, exts ...string) {
+	for i, ext := range exts {
+		if ext == "" {
+			continue
+		}
+		so.final[i] = filepath.Join(dir, base+ext)
+		so.tmp[i] = filepath.Join(dir, "."+base+ext)
+	}
+}
+
+// check returns an error if an output file already exists.
+// Input files that differ only in extensions, such as A.pcap and A.pcapng.gz, would otherwise overwrite each other.
+func (so *spoolOutput) check() error {
+	for _, final := range so.final {
+		if final == "" {
+			continue
+		}
+		if _, e := os.Stat(final); e == nil {
+			return fmt.Errorf("output file %s already exists", final)
+		}
+	}
+	return nil
+}
+
+func (so *spoolOutput) finish(ok bool) error {
+	errs := []error{}
+	for i, tmp := range so.tmp {
+		switch {
+		case tmp == "":
+		case ok:
+			errs = append(errs, os.Rename(tmp, so.final[i]))
+		default:
+			os.Remove(tmp)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func processSpoolFile(ctx context.Context, c *cli.Context, anon *ndntdump.Anonymizer, filename string) (e error) {
+	input, e := pcapinput.Open("", filename, c.StringSlice("local"))
+	if e != nil {
+		return e
+	}
+	stop := context.AfterFunc(ctx, func() { input.Close() })
+	defer func() {
+		if stop() {
+			input.Close()
+		}
+	}()
+	reader, e := newReader(c, input, anon)
+	if e != nil {
+		return e
+	}
+
+	outputOpts, e := newOutputOptions(c, c.Command.Flags, anon)
+	if e != nil {
+		return e
+	}
+
+	var so spoolOutput
+	so.init(c.String("output-dir"), traceBaseName(filepath.Base(filename)), c.String("json-ext"), c.String("pcapng-ext"))
+	if e = so.check(); e != nil {
+		return e
+	}
+	output, e := fileoutput.Open(so.tmp[0], so.tmp[1], outputOpts)
+	if e != nil {
+		so.finish(false)
+		return e
+	}
+
+	e = errors.Join(copyRecords(reader, output), output.Close())
+	printDiagCounters(c, filename, reader)
+	return errors.Join(e, so.finish(e == nil))
+}
+
+var spoolCommand = &cli.Command{
+	Name:  "spool",
+	Usage: "watch a directory and process trace files as they arrive",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:     "input-dir",
+			Usage:    "incoming trace files `directory`",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "output-dir",
+			Usage:    "sanitized output files `directory`",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "done-dir",
+			Usage:    "`directory` for successfully processed trace files",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "failed-dir",
+			Usage:    "`directory` for trace files that cannot be processed",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "journal",
+			Usage: "progress journal `filename` (default: .journal.ndjson in done-dir)",
+		},
+		&cli.StringFlag{
+			Name:  "json-ext",
+			Usage: "records output filename `extension`, empty to disable",
+			Value: ".json.gz",
+		},
+		&cli.StringFlag{
+			Name:  "pcapng-ext",
+			Usage: "packets output filename `extension`, empty to disable",
+			Value: ".pcapng.gz",
+		},
+	}, slices.Concat(readerFlags, outputFlags)...),
+	Action: func(c *cli.Context) error {
+		anon, e := newAnonymizer(c)
+		if e != nil {
+			return cli.Exit(e, 1)
+		}
+		if _, e := classify.ByName(c.StringSlice("classify")); e != nil {
+			return cli.Exit(e, 1)
+		}
+		if _, e := newOutputOptions(c, c.Command.Flags, anon); e != nil {
+			return cli.Exit(e, 1)
+		}
+		if e := os.MkdirAll(c.String("output-dir"), 0o755); e != nil {
+			return cli.Exit(e, 1)
+		}
+
+		s, e := spool.New(spool.Config{
+			InputDir:    c.String("input-dir"),
+			DoneDir:     c.String("done-dir"),
+			FailedDir:   c.String("failed-dir"),
+			JournalFile: c.String("journal"),
+			Process: func(ctx context.Context, filename string) error {
+				return processSpoolFile(ctx, c, anon, filename)
+			},
+			MoveError: func(filename string, e error) {
+				fmt.Fprintf(c.App.ErrWriter, "%s: %v\n", filename, e)
+			},
+		})
+		if e != nil {
+			return cli.Exit(e, 1)
+		}
+		defer s.Close()
+
+		ctx, stop := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		if e := s.Run(ctx); e != nil {
+			return cli.Exit(e, 1)
+		}
+		return nil
+	},
+}
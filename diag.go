@@ -0,0 +1,114 @@
+package ndntdump
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/usnistgov/ndn-dpdk/ndn/ndnlayer"
+)
+
+// DirTypeDiag is the DirType of a diagnostic record.
+const DirTypeDiag = "!"
+
+// DiagReason indicates why a captured frame was dropped or only partially parsed.
+type DiagReason string
+
+// DiagReason values.
+const (
+	DiagLinkType  DiagReason = "link-type" // unsupported link type
+	DiagFrame     DiagReason = "frame"     // frame cannot be decoded below NDN layer
+	DiagTLV       DiagReason = "tlv"       // invalid NDN TLV
+	DiagNDN       DiagReason = "ndn"       // TLV element is not a valid NDN packet
+	DiagDirection DiagReason = "direction" // traffic direction cannot be determined
+	DiagNotNDN    DiagReason = "not-ndn"   // frame does not carry NDN traffic
+	DiagWebSocket DiagReason = "websocket" // truncated or malformed WebSocket frame
+	DiagFragment  DiagReason = "fragment"  // first fragment does not contain a decodable name
+)
+
+// DiagCounters returns the number of diagnostic events per reason.
+// Events are counted regardless of ReaderOptions.Diagnostics.
+func (r *Reader) DiagCounters() map[DiagReason]uint64 {
+	return maps.Clone(r.diagCounters)
+}
+
+// diag counts a diagnostic event.
+// If diagnostics are enabled, it returns a diagnostic record.
+// Frames that do not carry NDN traffic are only counted unless DiagnosticsNotNDN is set,
+// because they are common on a shared link.
+func (r *Reader) diag(ci gopacket.CaptureInfo, face int, reason DiagReason, e error) (rec Record, ok bool) {
+	r.diagCounters[reason]++
+	if !r.diagnostics || (reason == DiagNotNDN && !r.diagNotNDN) {
+		return rec, false
+	}
+
+	rec = Record{
+		CaptureInfo: ci,
+		DirType:     DirTypeDiag,
+		Timestamp:   ci.Timestamp.UnixNano(),
+		Face:        face,
+		Diag:        reason,
+	}
+	if e != nil {
+		rec.DiagError = e.Error()
+	}
+	return rec, true
+}
+
+func (r *Reader) appendDiag(records []Record, ci gopacket.CaptureInfo, face int, reason DiagReason, e error) []Record {
+	if rec, ok := r.diag(ci, face, reason, e); ok {
+		records = append(records, rec)
+	}
+	return records
+}
+
+// decodeErrorReason determines which layer caused a decoding error.
+func (r *Reader) decodeErrorReason() DiagReason {
+	if len(r.decoded) == 0 {
+		return DiagFrame
+	}
+
+	var next gopacket.LayerType
+	switch r.decoded[len(r.decoded)-1] {
+	case layers.LayerTypeEthernet:
+		next = r.eth.NextLayerType()
+	case layers.LayerTypeLinuxSLL:
+		next = r.sll.NextLayerType()
+	case layers.LayerTypeIPv4:
+		next = r.ip4.NextLayerType()
+	case layers.LayerTypeIPv6:
+		next = r.ip6.NextLayerType()
+	case layers.LayerTypeUDP:
+		next = r.udp.NextLayerType()
+	case ndnlayer.LayerTypeTLV:
+		next = ndnlayer.LayerTypeNDN
+	}
+
+	switch next {
+	case ndnlayer.LayerTypeTLV:
+		return DiagTLV
+	case ndnlayer.LayerTypeNDN:
+		return DiagNDN
+	}
+	return DiagFrame
+}
+
+// unknownDirectionReason classifies a frame whose direction cannot be determined.
+// It is DiagDirection if the frame carries NDN traffic, otherwise DiagNotNDN.
+func (r *Reader) unknownDirectionReason() DiagReason {
+	if slices.Contains(r.decoded, ndnlayer.LayerTypeTLV) {
+		return DiagDirection
+	}
+	if slices.Contains(r.decoded, layers.LayerTypeTCP) {
+		switch r.tcpPort {
+		case r.tcp.SrcPort, r.tcp.DstPort:
+			return DiagDirection
+		}
+		switch r.wssPort {
+		case r.tcp.SrcPort, r.tcp.DstPort:
+			return DiagDirection
+		}
+	}
+	return DiagNotNDN
+}
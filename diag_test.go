@@ -0,0 +1,76 @@
+package ndntdump_test
+
+import (
+	"testing"
+
+	"github.com/gopacket/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/pcapinput"
+)
+
+func TestReaderDiag(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	arp := makeSLL(layers.LinuxSLLPacketTypeHost, nil)
+	arp[14], arp[15] = 0x08, 0x06
+	arp = append(arp, make([]byte, 28)...)
+
+	filename := writeNdndpdkTrace(t,
+		makeSLL(layers.LinuxSLLPacketTypeHost, ndn.MakeInterest("/A")),
+		append(makeSLL(layers.LinuxSLLPacketTypeHost, nil), 0x05, 0xFF), // truncated TLV
+		makeSLL(layers.LinuxSLLPacketTypeHost, tlv.TLV(0x99)),           // not an NDN packet
+		makeSLL(layers.LinuxSLLPacketTypeOtherhost, ndn.MakeInterest("/B")),
+		arp, // not NDN traffic
+		makeSLL(layers.LinuxSLLPacketTypeHost, ndn.MakeData("/C")),
+	)
+
+	for _, tc := range []struct {
+		diagnostics bool
+		notNDN      bool
+	}{{false, false}, {true, false}, {true, true}} {
+		input, e := pcapinput.Open("", filename, nil)
+		require.NoError(e)
+		defer input.Close()
+
+		opts := ndntdump.ReaderOptions{Diagnostics: tc.diagnostics, DiagnosticsNotNDN: tc.notNDN}
+		opts.IsLocal, opts.LinkType, opts.FaceID = input.IsLocal, input.LinkType, input.FaceID
+		opts.Anonymizer = ndntdump.NewAnonymizer(nil, true, nil)
+		reader := ndntdump.NewReader(input, opts)
+
+		var dirTypes []string
+		var reasons []ndntdump.DiagReason
+		for {
+			rec, e := reader.Read()
+			if e != nil {
+				break
+			}
+			dirTypes = append(dirTypes, rec.DirType)
+			if rec.DirType == ndntdump.DirTypeDiag {
+				reasons = append(reasons, rec.Diag)
+				assert.Nil(rec.Wire)
+				assert.Equal(1, rec.Face)
+			}
+		}
+
+		switch {
+		case tc.notNDN:
+			assert.Equal([]string{">I", "!", "!", "!", "!", ">D"}, dirTypes)
+			assert.Equal([]ndntdump.DiagReason{ndntdump.DiagTLV, ndntdump.DiagNDN, ndntdump.DiagDirection, ndntdump.DiagNotNDN}, reasons)
+		case tc.diagnostics:
+			assert.Equal([]string{">I", "!", "!", "!", ">D"}, dirTypes)
+			assert.Equal([]ndntdump.DiagReason{ndntdump.DiagTLV, ndntdump.DiagNDN, ndntdump.DiagDirection}, reasons)
+		default:
+			assert.Equal([]string{">I", ">D"}, dirTypes)
+		}
+		assert.Equal(map[ndntdump.DiagReason]uint64{
+			ndntdump.DiagTLV:       1,
+			ndntdump.DiagNDN:       1,
+			ndntdump.DiagDirection: 1,
+			ndntdump.DiagNotNDN:    1,
+		}, reader.DiagCounters())
+	}
+}
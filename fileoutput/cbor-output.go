@@ -0,0 +1,48 @@
+package fileoutput
+
+import (
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/usnistgov/ndntdump"
+)
+
+// CborOutput saves packet information in CBOR sequence file.
+// It can be read with ndntdump.RecordDecoder.
+type CborOutput struct {
+	cf  *compressedFile
+	enc *cbor.Encoder
+}
+
+func (o *CborOutput) Close() error {
+	return o.cf.Close()
+}
+
+func (o *CborOutput) Write(rec ndntdump.Record) error {
+	if len(rec.DirType) == 0 {
+		return nil
+	}
+	return o.enc.Encode(rec)
+}
+
+// NewCborOutput creates CborOutput.
+// hdr is written as the first item, with schema version and start time filled in.
+func NewCborOutput(filename string, hdr ndntdump.Header) (o *CborOutput, e error) {
+	cf, e := newCompressedFile(filename)
+	if e != nil {
+		return nil, e
+	}
+	return newCborOutput(cf, hdr)
+}
+
+func newCborOutput(cf *compressedFile, hdr ndntdump.Header) (o *CborOutput, e error) {
+	o = &CborOutput{cf: cf}
+	o.enc = ndntdump.NewCborEncoder(o.cf)
+
+	hdr.Type, hdr.Schema, hdr.Start = ndntdump.DirTypeHeader, ndntdump.SchemaVersion, time.Now()
+	if e = o.enc.Encode(ndntdump.CborHeader(hdr)); e != nil {
+		o.cf.Close()
+		return nil, e
+	}
+	return o, nil
+}
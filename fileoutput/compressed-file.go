@@ -34,6 +34,11 @@ func (cf *compressedFile) Close() error {
 	return errors.Join(errs...)
 }
 
+// newStreamFile wraps a stream writer, which is not closed by Close.
+func newStreamFile(w io.Writer) *compressedFile {
+	return &compressedFile{w: w}
+}
+
 func newCompressedFile(filename string) (cf *compressedFile, e error) {
 	cf = &compressedFile{
 		w: io.Discard,
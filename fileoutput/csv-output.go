@@ -0,0 +1,184 @@
+package fileoutput
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/usnistgov/ndntdump"
+)
+
+// DefaultCsvColumns is the default list of CsvOutput columns.
+var DefaultCsvColumns = []string{"t", "ts", "face", "flowId", "size2", "size3", "name", "nackReason", "lifetime", "hopLimit", "contentType", "freshness", "contentLen"}
+
+// csvColumn extracts a column value from a Record.
+type csvColumn []int
+
+func (col csvColumn) format(rec *ndntdump.Record) string {
+	v := reflect.ValueOf(rec).Elem()
+	for _, i := range col {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return ""
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return formatCsvValue(v)
+}
+
+// formatCsvValue formats a field value.
+// Zero values are written as empty cells.
+// Octets are written in hexadecimal, names are written as URI, and complex values are written as JSON.
+func formatCsvValue(v reflect.Value) string {
+	if v.IsZero() {
+		return ""
+	}
+	switch x := v.Interface().(type) {
+	case []byte:
+		return hex.EncodeToString(x)
+	case encoding.TextMarshaler:
+		text, _ := x.MarshalText()
+		return string(text)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	j, _ := json.Marshal(v.Interface())
+	return string(j)
+}
+
+// csvColumns maps column names to Record fields.
+// Fields of struct pointers, such as flowInfo and cp, are available as dotted names.
+var csvColumns = sync.OnceValue(func() map[string]csvColumn {
+	m := map[string]csvColumn{}
+	var walk func(typ reflect.Type, prefix string, index []int)
+	walk = func(typ reflect.Type, prefix string, index []int) {
+		for i := range typ.NumField() {
+			f := typ.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			fIndex := append(slices.Clone(index), i)
+			m[prefix+name] = fIndex
+			if f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct {
+				walk(f.Type.Elem(), prefix+name+".", fIndex)
+			}
+		}
+	}
+	walk(reflect.TypeFor[ndntdump.Record](), "", nil)
+	return m
+})
+
+// CsvColumns returns names of available CsvOutput columns.
+func CsvColumns() []string {
+	return slices.Sorted(maps.Keys(csvColumns()))
+}
+
+// CsvOptions contains CsvOutput options.
+type CsvOptions struct {
+	// Comma is the field delimiter.
+	// Default is ','.
+	Comma rune
+
+	// Columns is the list of columns, named after JSON property keys.
+	// Default is DefaultCsvColumns.
+	Columns []string
+}
+
+func (opts *CsvOptions) applyDefaults() (columns []csvColumn, e error) {
+	if opts.Comma == 0 {
+		opts.Comma = ','
+	}
+	if len(opts.Columns) == 0 {
+		opts.Columns = DefaultCsvColumns
+	}
+	for _, name := range opts.Columns {
+		col, ok := csvColumns()[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %s", name)
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// CsvOutput saves packet information in CSV or TSV file.
+// The first row contains column names.
+type CsvOutput struct {
+	cf      *compressedFile
+	w       *csv.Writer
+	columns []csvColumn
+	row     []string
+}
+
+func (o *CsvOutput) Close() error {
+	o.w.Flush()
+	return errors.Join(
+		o.w.Error(),
+		o.cf.Close(),
+	)
+}
+
+// Flush writes buffered rows to the underlying file.
+func (o *CsvOutput) Flush() error {
+	o.w.Flush()
+	return o.w.Error()
+}
+
+func (o *CsvOutput) Write(rec ndntdump.Record) error {
+	if len(rec.DirType) == 0 {
+		return nil
+	}
+	for i, col := range o.columns {
+		o.row[i] = col.format(&rec)
+	}
+	return o.w.Write(o.row)
+}
+
+// NewCsvOutput creates CsvOutput.
+func NewCsvOutput(filename string, opts CsvOptions) (o *CsvOutput, e error) {
+	if _, e = opts.applyDefaults(); e != nil {
+		return nil, e
+	}
+	cf, e := newCompressedFile(filename)
+	if e != nil {
+		return nil, e
+	}
+	return newCsvOutput(cf, opts)
+}
+
+func newCsvOutput(cf *compressedFile, opts CsvOptions) (o *CsvOutput, e error) {
+	o = &CsvOutput{cf: cf}
+	if o.columns, e = opts.applyDefaults(); e != nil {
+		cf.Close()
+		return nil, e
+	}
+	o.row = make([]string, len(o.columns))
+
+	o.w = csv.NewWriter(o.cf)
+	o.w.Comma = opts.Comma
+	if e = o.w.Write(opts.Columns); e != nil {
+		o.cf.Close()
+		return nil, e
+	}
+	return o, nil
+}
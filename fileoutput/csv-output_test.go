@@ -0,0 +1,62 @@
+package fileoutput_test
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/fileoutput"
+)
+
+func TestCsvOutput(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	filename := filepath.Join(t.TempDir(), "records.tsv.gz")
+	o, e := fileoutput.Open(filename, "", fileoutput.Options{
+		Csv: fileoutput.CsvOptions{
+			Columns: []string{"ts", "t", "name", "flow", "flowInfo.localPort", "cp.origin", "acks"},
+		},
+	})
+	require.NoError(e)
+	require.NoError(o.Write(ndntdump.Record{
+		DirType:   ">I",
+		Timestamp: 1700000000_000000001,
+		Name:      ndn.ParseName("/A/%09%22"),
+		Flow:      []byte{0xA0, 0xA1},
+		FlowInfo:  &ndntdump.FlowKey{LocalPort: 6363},
+		Acks:      []uint64{1, 2},
+	}))
+	require.NoError(o.Write(ndntdump.Record{}))
+	require.NoError(o.Write(ndntdump.Record{
+		DirType:       "<D",
+		Timestamp:     1700000000_000000002,
+		ControlParams: &ndntdump.ControlParameters{Origin: 65},
+	}))
+	require.NoError(o.Close())
+
+	f, e := os.Open(filename)
+	require.NoError(e)
+	defer f.Close()
+	gz, e := gzip.NewReader(f)
+	require.NoError(e)
+	r := csv.NewReader(gz)
+	r.Comma = '\t'
+	rows, e := r.ReadAll()
+	require.NoError(e)
+	assert.Equal([][]string{
+		{"ts", "t", "name", "flow", "flowInfo.localPort", "cp.origin", "acks"},
+		{"1700000000000000001", ">I", "/8=A/8=%09%22", "a0a1", "6363", "", "[1,2]"},
+		{"1700000000000000002", "<D", "", "", "", "65", ""},
+	}, rows)
+
+	_, e = fileoutput.Open(filepath.Join(t.TempDir(), "records.csv"), "", fileoutput.Options{
+		Csv: fileoutput.CsvOptions{Columns: []string{"unknown"}},
+	})
+	assert.Error(e)
+}
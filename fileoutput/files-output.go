@@ -3,33 +3,223 @@ package fileoutput
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/filter"
+	"github.com/usnistgov/ndntdump/sample"
 )
 
-// Open creates RecordOutput that writes to ndjson and pcapng files.
-func Open(ndjsonFilename, pcapngFilename string) (ro ndntdump.RecordOutput, e error) {
+// Records file formats.
+const (
+	FormatNdjson  = "ndjson"
+	FormatParquet = "parquet"
+	FormatCsv     = "csv"
+	FormatTsv     = "tsv"
+	FormatSqlite  = "sqlite"
+	FormatCbor    = "cbor"
+)
+
+// RecordsFormat determines records file format from filename extension.
+// Compression extensions are skipped, but Open rejects them for Parquet and SQLite formats.
+func RecordsFormat(filename string) string {
+	switch ext := filepath.Ext(filename); ext {
+	case ".gz", ".zst":
+		return RecordsFormat(strings.TrimSuffix(filename, ext))
+	case ".parquet":
+		return FormatParquet
+	case ".csv":
+		return FormatCsv
+	case ".tsv":
+		return FormatTsv
+	case ".cbor":
+		return FormatCbor
+	case ".sqlite", ".sqlite3", ".db":
+		return FormatSqlite
+	}
+	return FormatNdjson
+}
+
+// checkUncompressed returns an error if filename has a compression extension.
+// Parquet and SQLite files cannot be read through a compression layer, so that they are never compressed.
+func checkUncompressed(format, filename string) error {
+	switch ext := filepath.Ext(filename); ext {
+	case ".gz", ".zst":
+		return fmt.Errorf("%s records cannot be compressed with %s extension", format, ext)
+	}
+	return nil
+}
+
+// Options contains Open options.
+type Options struct {
+	// Header is written at the start of each records file.
+	Header ndntdump.Header
+
+	// RecordsFormat is the records file format.
+	// If empty, it is determined from records filename extension.
+	RecordsFormat string
+
+	// Parquet contains ParquetOutput options.
+	Parquet ParquetOptions
+
+	// Csv contains CsvOutput options.
+	// Comma is set by RecordsFormat.
+	Csv CsvOptions
+
+	// Pcapng contains PcapngOutput options.
+	Pcapng PcapngOptions
+
+	// Sqlite contains SqliteOutput options.
+	Sqlite SqliteOptions
+
+	// Rotate contains automatic rotation options, applied to both records and pcapng files.
+	Rotate RotateOptions
+
+	// Stream contains StreamOutput options, applied to listener addresses.
+	Stream StreamOptions
+
+	// RecordsFilter selects records written to records file.
+	// nil selects all records.
+	RecordsFilter *filter.Filter
+
+	// PcapngFilter selects packets written to pcapng file.
+	// nil selects all packets.
+	PcapngFilter *filter.Filter
+
+	// Sampler selects packets written to both records and pcapng files, before filters are applied.
+	// Its configuration is recorded in Header.
+	// nil writes all packets.
+	Sampler *sample.Sampler
+}
+
+// Open creates RecordOutput that writes to records and pcapng files.
+//
+// Either filename may be "-" to write to standard output, or a listener address such as
+// "unix:/run/ndntdump.sock" or "tcp:127.0.0.1:6380" to stream to subscribers via StreamOutput.
+// Rotation does not apply to streams.
+func Open(recordsFilename, pcapngFilename string, opts Options) (ro ndntdump.RecordOutput, e error) {
+	if recordsFilename == StdoutFilename && pcapngFilename == StdoutFilename {
+		return nil, errors.New("records and pcapng cannot both be written to stdout")
+	}
+	opts.Header.Sampling = opts.Sampler.Info()
 	o := make(sliceOutput, 0, 2)
 
-	if ndjsonFilename != "" {
-		ndjson, e := NewLogrotateOutput(ndjsonFilename, NewNdjsonOutput)
+	if recordsFilename != "" {
+		records, e := openRecords(recordsFilename, opts)
 		if e != nil {
 			o.Close()
 			return nil, e
 		}
-		o = append(o, ndjson)
+		o = append(o, filter.NewOutput(opts.RecordsFilter, records))
 	}
 
 	if pcapngFilename != "" {
-		pcapng, e := NewLogrotateOutput(pcapngFilename, NewPcapngOutput)
+		pcapng, e := openOutput(pcapngFilename, opts, func(cf *compressedFile) (ndntdump.RecordOutput, error) {
+			return newPcapngOutput(cf, opts.Pcapng)
+		})
 		if e != nil {
 			o.Close()
 			return nil, e
 		}
-		o = append(o, pcapng)
+		o = append(o, filter.NewOutput(opts.PcapngFilter, pcapng))
+	}
+
+	return sample.NewOutput(opts.Sampler, o), nil
+}
+
+func openRecords(filename string, opts Options) (ndntdump.RecordOutput, error) {
+	format := opts.RecordsFormat
+	if format == "" {
+		format = RecordsFormat(filename)
+	}
+
+	switch format {
+	case FormatParquet, FormatSqlite:
+		// a Parquet file is readable only after its footer is written, and a SQLite database is not a stream
+		if _, _, isStream := ParseStreamAddr(filename); isStream || filename == StdoutFilename {
+			return nil, fmt.Errorf("%s records cannot be streamed", format)
+		}
+		if e := checkUncompressed(format, filename); e != nil {
+			return nil, e
+		}
+	}
+
+	switch format {
+	case FormatNdjson:
+		return openOutput(filename, opts, func(cf *compressedFile) (ndntdump.RecordOutput, error) {
+			return newNdjsonOutput(cf, opts.Header)
+		})
+	case FormatCbor:
+		return openOutput(filename, opts, func(cf *compressedFile) (ndntdump.RecordOutput, error) {
+			return newCborOutput(cf, opts.Header)
+		})
+	case FormatParquet:
+		return openOutput(filename, opts, func(cf *compressedFile) (ndntdump.RecordOutput, error) {
+			return newParquetOutput(cf, opts.Header, opts.Parquet)
+		})
+	case FormatCsv, FormatTsv:
+		opts.Csv.Comma = ','
+		if format == FormatTsv {
+			opts.Csv.Comma = '\t'
+		}
+		return openOutput(filename, opts, func(cf *compressedFile) (ndntdump.RecordOutput, error) {
+			return newCsvOutput(cf, opts.Csv)
+		})
+	case FormatSqlite:
+		return NewLogrotateOutput(filename, opts.Rotate, func(filename string) (*SqliteOutput, error) {
+			return NewSqliteOutput(filename, opts.Header, opts.Sqlite)
+		})
+	}
+	return nil, fmt.Errorf("unknown records format %s", format)
+}
+
+// openOutput creates a file-based, stdout, or stream RecordOutput.
+func openOutput(filename string, opts Options, create func(cf *compressedFile) (ndntdump.RecordOutput, error)) (ndntdump.RecordOutput, error) {
+	if filename == StdoutFilename {
+		output, e := create(newStreamFile(os.Stdout))
+		if e != nil {
+			return nil, e
+		}
+		return flushOutput{output}, nil
 	}
 
-	return o, nil
+	if network, addr, ok := ParseStreamAddr(filename); ok {
+		return NewStreamOutput(network, addr, opts.Stream, func(w io.Writer) (ndntdump.RecordOutput, error) {
+			return create(newStreamFile(w))
+		})
+	}
+
+	return NewLogrotateOutput(filename, opts.Rotate, func(filename string) (ndntdump.RecordOutput, error) {
+		cf, e := newCompressedFile(filename)
+		if e != nil {
+			return nil, e
+		}
+		return create(cf)
+	})
+}
+
+// flushOutput flushes after each record, so that a stdout consumer receives records promptly.
+type flushOutput struct {
+	ndntdump.RecordOutput
+}
+
+func (o flushOutput) Write(rec ndntdump.Record) error {
+	if e := o.RecordOutput.Write(rec); e != nil {
+		return e
+	}
+	if f, ok := o.RecordOutput.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Join combines several RecordOutputs into one.
+func Join(outputs ...ndntdump.RecordOutput) ndntdump.RecordOutput {
+	return sliceOutput(outputs)
 }
 
 type sliceOutput []ndntdump.RecordOutput
@@ -1,66 +1,289 @@
 package fileoutput
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/ncruces/go-strftime"
 	"github.com/usnistgov/ndntdump"
 )
 
-// LogrotateOutput wraps a file-based RecordOutput to reopen the file upon SIGHUP.
+// RotateOptions contains automatic rotation options of LogrotateOutput.
+type RotateOptions struct {
+	// Interval rotates the file at wall-clock boundaries that are multiples of this duration.
+	// Zero disables time-based rotation.
+	Interval time.Duration
+
+	// MaxSize rotates the file after its size reaches this many octets.
+	// It applies to the file size on disk, which counts compressed octets for gzip and zstd files
+	// and excludes data still buffered in the compressor, so that the limit may be overshot.
+	// File size is checked at most once per second.
+	// Zero disables size-based rotation.
+	MaxSize int64
+
+	// MaxPackets rotates the file after this many records are written.
+	// Zero disables count-based rotation.
+	MaxPackets int
+
+	// MaxFiles is the maximum number of files retained, including the current file.
+	// Older files created by this LogrotateOutput are deleted.
+	// Zero means unlimited.
+	MaxFiles int
+
+	// Hook is a command executed after a file is closed, with the filename appended as the last argument.
+	// Empty disables the hook.
+	Hook []string
+}
+
+func (opts RotateOptions) enabled() bool {
+	return opts.Interval > 0 || opts.MaxSize > 0 || opts.MaxPackets > 0
+}
+
+// sizeCheckInterval is the minimum interval between file size checks.
+const sizeCheckInterval = time.Second
+
+// LogrotateOutput wraps a file-based RecordOutput to reopen the file upon SIGHUP,
+// or rotate the file automatically according to RotateOptions.
+//
+// The filename may contain strftime-style specifiers such as %Y%m%d-%H%M%S, which are expanded upon each reopen.
+// If automatic rotation is enabled but the filename has no specifier, a timestamp is inserted before the extension.
 type LogrotateOutput[T ndntdump.RecordOutput] struct {
-	filename string
-	mu       sync.Mutex
-	sighup   chan os.Signal
-	output   T
-	create   func(string) (T, error)
+	template  string
+	opts      RotateOptions
+	mu        sync.Mutex
+	sighup    chan os.Signal
+	timer     *time.Timer
+	hooks     sync.WaitGroup
+	output    T
+	create    func(string) (T, error)
+	filename  string
+	files     []retainedFile
+	packets   int
+	sizeCheck time.Time
+	closing   bool
+	opened    bool
+	err       error
+	lastBase  string
+	lastSeq   int
 }
 
-func (o *LogrotateOutput[T]) reopen(first bool) error {
-	o.mu.Lock()
-	defer o.mu.Unlock()
+// expandFilename determines the filename of a new file.
+func (o *LogrotateOutput[T]) expandFilename(t time.Time) string {
+	filename := o.template
+	switch {
+	case strings.Contains(o.template, "%"):
+		filename = strftime.Format(o.template, t)
+	case o.opts.enabled():
+		filename = insertBeforeExt(o.template, "-"+t.Format("20060102T150405"))
+	}
+	if !o.opts.enabled() {
+		return filename
+	}
 
-	output, e := o.create(o.filename)
-	if e != nil {
-		return e
+	// append a sequence number if the filename exists or has been used, such as after size-based rotation within the same second
+	seq := 0
+	if filename == o.lastBase {
+		seq = o.lastSeq + 1
 	}
+	o.lastBase = filename
+	for ; ; seq++ {
+		name := filename
+		if seq > 0 {
+			name = insertBeforeExt(filename, fmt.Sprintf("-%d", seq))
+		}
+		if _, e := os.Stat(name); errors.Is(e, os.ErrNotExist) {
+			o.lastSeq = seq
+			return name
+		}
+	}
+}
+
+// knownExts are filename extensions recognized by insertBeforeExt.
+var knownExts = []string{
+	".json", ".ndjson", ".jsonl", ".cbor", ".parquet", ".csv", ".tsv", ".sqlite", ".sqlite3", ".db",
+	".pcapng", ".pcap",
+}
 
-	if !first {
-		o.output.Close()
+// insertBeforeExt inserts a string before the file format and compression extensions of a filename.
+func insertBeforeExt(filename, s string) string {
+	stem, ext := filename, ""
+	for _, exts := range [][]string{{".gz", ".zst"}, knownExts} {
+		if e := filepath.Ext(stem); slices.Contains(exts, e) && filepath.Base(stem) != e {
+			stem, ext = strings.TrimSuffix(stem, e), e+ext
+		}
+	}
+	return stem + s + ext
+}
+
+// reopen closes the current file, if any, and creates a new file.
+// The current file is closed first, so that creating a file at the same path does not disturb the open file.
+// If the new file cannot be created, the interval timer is still armed, and Write retries.
+func (o *LogrotateOutput[T]) reopen(first bool, t time.Time) (e error) {
+	if o.opened {
+		o.opened = false
+		e = o.output.Close()
+		o.retain(o.filename, o.runHook(o.filename))
+	}
+
+	filename := o.expandFilename(t)
+	output, eCreate := o.create(filename)
+	if eCreate == nil {
+		o.output, o.filename, o.opened = output, filename, true
+	} else if first {
+		return eCreate
+	}
+	o.packets, o.sizeCheck = 0, t
+
+	if o.opts.Interval > 0 {
+		next := t.Truncate(o.opts.Interval).Add(o.opts.Interval)
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+		o.timer = time.AfterFunc(time.Until(next), func() {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+			if !o.closing {
+				o.deferError(o.reopen(false, next))
+			}
+		})
+	}
+	return errors.Join(e, eCreate)
+}
+
+// deferError saves an error from a background reopen, to be reported by the next Write or Close.
+func (o *LogrotateOutput[T]) deferError(e error) {
+	o.err = errors.Join(o.err, e)
+}
+
+// runHook runs the hook command on a closed file.
+// Its stdout is redirected to stderr, because records may be written to stdout.
+// Returns a channel that is closed when the hook completes.
+func (o *LogrotateOutput[T]) runHook(filename string) <-chan struct{} {
+	done := make(chan struct{})
+	if len(o.opts.Hook) == 0 {
+		close(done)
+		return done
+	}
+
+	cmd := exec.Command(o.opts.Hook[0], append(o.opts.Hook[1:], filename)...)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if cmd.Start() != nil {
+		close(done)
+		return done
+	}
+	o.hooks.Add(1)
+	go func() {
+		defer o.hooks.Done()
+		defer close(done)
+		cmd.Wait()
+	}()
+	return done
+}
+
+type retainedFile struct {
+	filename string
+	hook     <-chan struct{}
+}
+
+// retain deletes old files, so that no more than MaxFiles files exist including the current file.
+// A file is deleted after its hook completes.
+func (o *LogrotateOutput[T]) retain(filename string, hook <-chan struct{}) {
+	if o.opts.MaxFiles <= 0 || filename == o.template {
+		return
+	}
+	o.files = append(o.files, retainedFile{filename, hook})
+	for len(o.files) >= o.opts.MaxFiles {
+		old := o.files[0]
+		o.files = o.files[1:]
+		o.hooks.Add(1)
+		go func() {
+			defer o.hooks.Done()
+			<-old.hook
+			os.Remove(old.filename)
+		}()
 	}
-	o.output = output
-	return nil
 }
 
 func (o *LogrotateOutput[T]) Close() error {
 	signal.Stop(o.sighup)
 	close(o.sighup)
-	return o.output.Close()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closing = true
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	e := o.err
+	if o.opened {
+		o.opened = false
+		e = errors.Join(e, o.output.Close())
+		o.runHook(o.filename)
+	}
+	o.hooks.Wait()
+	return e
 }
 
 func (o *LogrotateOutput[T]) Write(rec ndntdump.Record) error {
 	o.mu.Lock()
 	defer o.mu.Unlock()
-	return o.output.Write(rec)
+	if e := o.err; e != nil {
+		o.err = nil
+		return e
+	}
+	if !o.opened {
+		// retry after a failed reopen
+		if e := o.reopen(false, time.Now()); e != nil {
+			return e
+		}
+	}
+	if e := o.output.Write(rec); e != nil {
+		return e
+	}
+
+	o.packets++
+	if o.opts.MaxPackets > 0 && o.packets >= o.opts.MaxPackets {
+		return o.reopen(false, time.Now())
+	}
+	if now := time.Now(); o.opts.MaxSize > 0 && now.Sub(o.sizeCheck) >= sizeCheckInterval {
+		o.sizeCheck = now
+		if st, e := os.Stat(o.filename); e == nil && st.Size() >= o.opts.MaxSize {
+			return o.reopen(false, now)
+		}
+	}
+	return nil
 }
 
 // NewLogrotateOutput creates LogrotateOutput.
-func NewLogrotateOutput[T ndntdump.RecordOutput](filename string, create func(string) (T, error)) (o *LogrotateOutput[T], e error) {
+func NewLogrotateOutput[T ndntdump.RecordOutput](filename string, opts RotateOptions, create func(string) (T, error)) (o *LogrotateOutput[T], e error) {
 	o = &LogrotateOutput[T]{
-		filename: filename,
+		template: filename,
+		opts:     opts,
 		sighup:   make(chan os.Signal, 1),
 		create:   create,
 	}
-	if e = o.reopen(true); e != nil {
+	o.mu.Lock()
+	e = o.reopen(true, time.Now())
+	o.mu.Unlock()
+	if e != nil {
 		return nil, e
 	}
 
 	signal.Notify(o.sighup, syscall.SIGHUP)
 	go func() {
 		for range o.sighup {
-			o.reopen(false)
+			o.mu.Lock()
+			o.deferError(o.reopen(false, time.Now()))
+			o.mu.Unlock()
 		}
 	}()
 
@@ -0,0 +1,151 @@
+package fileoutput_test
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/fileoutput"
+)
+
+func TestLogrotateOutputPackets(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	o, e := fileoutput.Open(filepath.Join(dir, "records-%Y.csv"), "", fileoutput.Options{
+		Rotate: fileoutput.RotateOptions{
+			MaxPackets: 2,
+			MaxFiles:   2,
+			Hook:       []string{"sh", "-c", `cp "$1" "$1.done"`, "hook"},
+		},
+	})
+	require.NoError(e)
+	for range 7 {
+		require.NoError(o.Write(ndntdump.Record{DirType: ">I"}))
+	}
+	require.NoError(o.Close())
+
+	year := time.Now().Format("2006")
+	matches, _ := filepath.Glob(filepath.Join(dir, "records-*.csv"))
+	assert.ElementsMatch([]string{
+		filepath.Join(dir, "records-"+year+"-2.csv"),
+		filepath.Join(dir, "records-"+year+"-3.csv"),
+	}, matches)
+
+	matches, _ = filepath.Glob(filepath.Join(dir, "*.done"))
+	assert.Len(matches, 4)
+	lastDone, e := os.ReadFile(filepath.Join(dir, "records-"+year+"-3.csv.done"))
+	require.NoError(e)
+	assert.Equal("t,ts,face,flowId,size2,size3,name,nackReason,lifetime,hopLimit,contentType,freshness,contentLen\n>I,,,,,,,,,,,,\n", string(lastDone))
+}
+
+func TestLogrotateOutputInterval(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	o, e := fileoutput.Open(filepath.Join(dir, "records.json"), filepath.Join(dir, "packets.pcapng"), fileoutput.Options{
+		Rotate: fileoutput.RotateOptions{Interval: 200 * time.Millisecond},
+	})
+	require.NoError(e)
+	countFiles := func(pattern string) int {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		return len(matches)
+	}
+	require.Eventually(func() bool {
+		return countFiles("records-*.json") >= 3 && countFiles("packets-*.pcapng") >= 3
+	}, 5*time.Second, 50*time.Millisecond)
+	require.NoError(o.Close())
+	assert.GreaterOrEqual(countFiles("records-*.json"), 3)
+}
+
+func TestLogrotateOutputReopenError(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	var created atomic.Int32
+	o, e := fileoutput.NewLogrotateOutput("records.txt", fileoutput.RotateOptions{Interval: 100 * time.Millisecond},
+		func(string) (ndntdump.RecordOutput, error) {
+			if created.Add(1) == 2 {
+				return nil, errors.New("disk full")
+			}
+			return newLineOutput(io.Discard)
+		})
+	require.NoError(e)
+
+	// the error is reported by the next Write, and interval rotation continues
+	var writeErr error
+	require.Eventually(func() bool {
+		if e := o.Write(ndntdump.Record{}); e != nil {
+			writeErr = e
+		}
+		return created.Load() >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.ErrorContains(writeErr, "disk full")
+	require.NoError(o.Close())
+}
+
+func TestLogrotateOutputSqliteSighup(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "records.db")
+	o, e := fileoutput.Open(filename, "", fileoutput.Options{})
+	require.NoError(e)
+	require.NoError(o.Write(ndntdump.Record{DirType: ">I"}))
+
+	// logrotate renames the database while its last batch is uncommitted
+	require.NoError(os.Rename(filename, filename+".1"))
+	require.NoError(syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	require.Eventually(func() bool {
+		_, e := os.Stat(filename)
+		return e == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.ErrorContains(o.Close(), "moved while open")
+
+	// the write-ahead log of the moved database is set aside, not deleted
+	aside, _ := filepath.Glob(filename + "-wal.*")
+	require.Len(aside, 1)
+	require.NoError(os.Rename(aside[0], filename+".1-wal"))
+	db, e := sql.Open("sqlite", filename+".1")
+	require.NoError(e)
+	defer db.Close()
+	var n int
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM packets").Scan(&n))
+	assert.Equal(1, n)
+}
+
+func TestLogrotateOutputFilename(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	for template, expected := range map[string]string{
+		"site.example.json.gz": `^site\.example-\d{8}T\d{6}(-1)?\.json\.gz$`,
+		"dir.d/records.pcapng": `^dir\.d/records-\d{8}T\d{6}(-1)?\.pcapng$`,
+		"records.zst":          `^records-\d{8}T\d{6}(-1)?\.zst$`,
+		"records":              `^records-\d{8}T\d{6}(-1)?$`,
+		".json":                `^\.json-\d{8}T\d{6}(-1)?$`,
+	} {
+		var names []string
+		o, e := fileoutput.NewLogrotateOutput(template, fileoutput.RotateOptions{MaxPackets: 1},
+			func(filename string) (ndntdump.RecordOutput, error) {
+				names = append(names, filename)
+				return newLineOutput(io.Discard)
+			})
+		require.NoError(e)
+		require.NoError(o.Write(ndntdump.Record{}))
+		require.NoError(o.Close())
+
+		require.Len(names, 2)
+		for _, name := range names {
+			assert.Regexp(expected, name, template)
+		}
+		assert.NotEqual(names[0], names[1], template)
+	}
+}
@@ -2,6 +2,7 @@ package fileoutput
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/usnistgov/ndntdump"
 )
@@ -24,11 +25,23 @@ func (o *NdjsonOutput) Write(rec ndntdump.Record) error {
 }
 
 // NewNdjsonOutput creates NdjsonOutput.
-func NewNdjsonOutput(filename string) (o *NdjsonOutput, e error) {
-	o = &NdjsonOutput{}
-	if o.cf, e = newCompressedFile(filename); e != nil {
+// hdr is written as the first line, with schema version and start time filled in.
+func NewNdjsonOutput(filename string, hdr ndntdump.Header) (o *NdjsonOutput, e error) {
+	cf, e := newCompressedFile(filename)
+	if e != nil {
 		return nil, e
 	}
+	return newNdjsonOutput(cf, hdr)
+}
+
+func newNdjsonOutput(cf *compressedFile, hdr ndntdump.Header) (o *NdjsonOutput, e error) {
+	o = &NdjsonOutput{cf: cf}
 	o.enc = json.NewEncoder(o.cf)
+
+	hdr.Type, hdr.Schema, hdr.Start = ndntdump.DirTypeHeader, ndntdump.SchemaVersion, time.Now()
+	if e = o.enc.Encode(hdr); e != nil {
+		o.cf.Close()
+		return nil, e
+	}
 	return o, nil
 }
@@ -0,0 +1,364 @@
+package fileoutput
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+)
+
+// ParquetHeaderKey is the key-value metadata key of the records file header in a Parquet file.
+const ParquetHeaderKey = "ndntdump.header"
+
+var parquetCodecs = map[string]compress.Codec{
+	"none":   &parquet.Uncompressed,
+	"snappy": &parquet.Snappy,
+	"gzip":   &parquet.Gzip,
+	"zstd":   &parquet.Zstd,
+	"lz4":    &parquet.Lz4Raw,
+	"brotli": &parquet.Brotli,
+}
+
+// ParquetCodecs returns names of supported Parquet compression codecs.
+func ParquetCodecs() []string {
+	return slices.Sorted(maps.Keys(parquetCodecs))
+}
+
+// ParquetOptions contains ParquetOutput options.
+type ParquetOptions struct {
+	// Compression is the compression codec name, see ParquetCodecs.
+	// Default is zstd.
+	Compression string
+
+	// RowGroupRows is the maximum number of rows in a row group.
+	// Default is 1048576.
+	RowGroupRows int
+
+	// RowGroupBytes is the maximum size of a row group, estimated from uncompressed column values.
+	// Zero means no size limit.
+	RowGroupBytes int64
+
+	// RowGroupInterval is the maximum duration between row group flushes.
+	// It is checked when a record is written, so that an idle output is not flushed.
+	// Zero means no time limit.
+	RowGroupInterval time.Duration
+}
+
+func (opts *ParquetOptions) applyDefaults() error {
+	if opts.Compression == "" {
+		opts.Compression = "zstd"
+	}
+	if _, ok := parquetCodecs[opts.Compression]; !ok {
+		return fmt.Errorf("unknown Parquet compression %s, available: %s", opts.Compression, strings.Join(ParquetCodecs(), " "))
+	}
+	if opts.RowGroupRows <= 0 {
+		opts.RowGroupRows = 1 << 20
+	}
+	return nil
+}
+
+// ParquetOutput saves packet information in Apache Parquet file.
+// Each Record field is a typed column, named after its JSON property key.
+type ParquetOutput struct {
+	cf        *compressedFile
+	w         *parquet.GenericWriter[parquetRecord]
+	opts      ParquetOptions
+	rows      int
+	bytes     int64
+	row       parquet.Row
+	lastFlush time.Time
+}
+
+func (o *ParquetOutput) Close() error {
+	return errors.Join(
+		o.w.Close(),
+		o.cf.Close(),
+	)
+}
+
+func (o *ParquetOutput) Write(rec ndntdump.Record) error {
+	if len(rec.DirType) == 0 {
+		return nil
+	}
+	pr := makeParquetRecord(rec)
+	if _, e := o.w.Write([]parquetRecord{pr}); e != nil {
+		return e
+	}
+
+	o.rows++
+	if o.opts.RowGroupBytes > 0 {
+		o.bytes += o.rowSize(&pr)
+	}
+	if o.rows >= o.opts.RowGroupRows || (o.opts.RowGroupBytes > 0 && o.bytes >= o.opts.RowGroupBytes) || (o.opts.RowGroupInterval > 0 && time.Since(o.lastFlush) >= o.opts.RowGroupInterval) {
+		return o.flush()
+	}
+	return nil
+}
+
+func (o *ParquetOutput) flush() error {
+	o.rows, o.bytes, o.lastFlush = 0, 0, time.Now()
+	return o.w.Flush()
+}
+
+// rowSize estimates the uncompressed size of a row, as the sum of its column values.
+// The writer does not expose its buffered size, so that this is used in place of it.
+func (o *ParquetOutput) rowSize(pr *parquetRecord) (size int64) {
+	o.row = o.w.Schema().Deconstruct(o.row[:0], pr)
+	for _, v := range o.row {
+		switch {
+		case v.IsNull():
+		case v.Kind() == parquet.ByteArray || v.Kind() == parquet.FixedLenByteArray:
+			size += int64(len(v.ByteArray()))
+		default:
+			size += 8
+		}
+	}
+	return size
+}
+
+// NewParquetOutput creates ParquetOutput.
+// hdr is saved in key-value metadata, with schema version and start time filled in.
+func NewParquetOutput(filename string, hdr ndntdump.Header, opts ParquetOptions) (o *ParquetOutput, e error) {
+	if e = opts.applyDefaults(); e != nil {
+		return nil, e
+	}
+	if e = checkUncompressed(FormatParquet, filename); e != nil {
+		return nil, e
+	}
+	cf, e := newCompressedFile(filename)
+	if e != nil {
+		return nil, e
+	}
+	return newParquetOutput(cf, hdr, opts)
+}
+
+func newParquetOutput(cf *compressedFile, hdr ndntdump.Header, opts ParquetOptions) (o *ParquetOutput, e error) {
+	if e = opts.applyDefaults(); e != nil {
+		cf.Close()
+		return nil, e
+	}
+
+	hdr.Type, hdr.Schema, hdr.Start = ndntdump.DirTypeHeader, ndntdump.SchemaVersion, time.Now()
+	hdrJSON, e := json.Marshal(hdr)
+	if e != nil {
+		cf.Close()
+		return nil, e
+	}
+
+	o = &ParquetOutput{
+		cf:        cf,
+		opts:      opts,
+		lastFlush: time.Now(),
+	}
+	o.w = parquet.NewGenericWriter[parquetRecord](o.cf,
+		parquet.Compression(parquetCodecs[opts.Compression]),
+		parquet.KeyValueMetadata(ParquetHeaderKey, string(hdrJSON)),
+	)
+	return o, nil
+}
+
+type parquetRecord struct {
+	DirType   string          `parquet:"t,dict"`
+	Timestamp int64           `parquet:"ts,timestamp(nanosecond)"`
+	Face      int             `parquet:"face,optional"`
+	Flow      []byte          `parquet:"flow,optional"`
+	FlowID    string          `parquet:"flowId,optional,dict"`
+	FlowInfo  *parquetFlowKey `parquet:"flowInfo,optional"`
+	Size2     int             `parquet:"size2"`
+
+	Diag      string `parquet:"diag,optional,dict"`
+	DiagError string `parquet:"diagError,optional"`
+
+	LpSeq        uint64   `parquet:"lpSeq,optional"`
+	FragIndex    int      `parquet:"fragIndex,optional"`
+	FragCount    int      `parquet:"fragCount,optional"`
+	PitToken     []byte   `parquet:"pitToken,optional"`
+	CongMark     int      `parquet:"congMark,optional"`
+	TxSeq        uint64   `parquet:"txSeq,optional"`
+	Acks         []uint64 `parquet:"acks,list"`
+	NextHopFace  int      `parquet:"nextHopFace,optional"`
+	IncomingFace int      `parquet:"incomingFace,optional"`
+	CachePolicy  int      `parquet:"cachePolicy,optional"`
+
+	Size3        int               `parquet:"size3,optional"`
+	NackReason   int               `parquet:"nackReason,optional"`
+	Name         string            `parquet:"name,optional"`
+	NameLen      int               `parquet:"nameLen,optional"`
+	NameSize     int               `parquet:"nameSize,optional"`
+	NameComps    []parquetNameComp `parquet:"nameComps,list"`
+	Prefix       string            `parquet:"prefix,optional,dict"`
+	CanBePrefix  bool              `parquet:"cbp"`
+	MustBeFresh  bool              `parquet:"mbf"`
+	FwHint       []string          `parquet:"fwHint,list"`
+	Lifetime     int               `parquet:"lifetime,optional"`
+	HopLimit     int               `parquet:"hopLimit,optional"`
+	Nonce        []byte            `parquet:"nonce,optional"`
+	AppParams    bool              `parquet:"appParams"`
+	AppParamsLen int               `parquet:"appParamsLen,optional"`
+	ParamsDigest []byte            `parquet:"paramsDigest,optional"`
+	SigNonce     []byte            `parquet:"sigNonce,optional"`
+	SigTime      int64             `parquet:"sigTime,optional,timestamp(millisecond)"`
+	SigSeqNum    uint64            `parquet:"sigSeqNum,optional"`
+	SigType      string            `parquet:"sigType,optional,dict"`
+	KeyLocator   string            `parquet:"keyLocator,optional,dict"`
+	KeyDigest    []byte            `parquet:"keyDigest,optional"`
+	SigValueLen  int               `parquet:"sigValueLen,optional"`
+	ContentType  int               `parquet:"contentType,optional"`
+	Freshness    int               `parquet:"freshness,optional"`
+	FinalBlock   bool              `parquet:"finalBlock"`
+	ContentLen   int               `parquet:"contentLen,optional"`
+	Digest       []byte            `parquet:"digest,optional"`
+
+	Proto         string                    `parquet:"proto,optional,dict"`
+	ProtoOp       string                    `parquet:"protoOp,optional,dict"`
+	ControlParams *parquetControlParameters `parquet:"cp,optional"`
+	SyncGroup     string                    `parquet:"syncGroup,optional,dict"`
+	StateVector   []parquetSVEntry          `parquet:"sv,list"`
+	IBFSize       int                       `parquet:"ibfSize,optional"`
+}
+
+type parquetFlowKey struct {
+	Transport  string `parquet:"transport,optional,dict"`
+	WebSocket  bool   `parquet:"websocket"`
+	LocalMAC   string `parquet:"localMAC,optional"`
+	RemoteMAC  string `parquet:"remoteMAC,optional"`
+	LocalIP    string `parquet:"localIP,optional"`
+	RemoteIP   string `parquet:"remoteIP,optional"`
+	LocalPort  int    `parquet:"localPort,optional"`
+	RemotePort int    `parquet:"remotePort,optional"`
+}
+
+type parquetNameComp struct {
+	Type  string  `parquet:"t,dict"`
+	Value string  `parquet:"v,optional"`
+	Num   *uint64 `parquet:"n,optional"`
+}
+
+type parquetControlParameters struct {
+	Name            string `parquet:"name,optional"`
+	FaceID          int    `parquet:"faceId,optional"`
+	URI             string `parquet:"uri,optional"`
+	LocalURI        string `parquet:"localUri,optional"`
+	Origin          int    `parquet:"origin,optional"`
+	Cost            int    `parquet:"cost,optional"`
+	Capacity        int    `parquet:"capacity,optional"`
+	Count           int    `parquet:"count,optional"`
+	Flags           int    `parquet:"flags,optional"`
+	Mask            int    `parquet:"mask,optional"`
+	Strategy        string `parquet:"strategy,optional"`
+	Expiration      int    `parquet:"expiration,optional"`
+	FacePersistency int    `parquet:"facePersistency,optional"`
+	Mtu             int    `parquet:"mtu,optional"`
+}
+
+type parquetSVEntry struct {
+	Node string `parquet:"node"`
+	Seq  uint64 `parquet:"seq"`
+	Boot uint64 `parquet:"boot,optional"`
+}
+
+// nameString converts a name to URI, or empty string if the name is empty.
+func nameString(name ndn.Name) string {
+	if len(name) == 0 {
+		return ""
+	}
+	return name.String()
+}
+
+func makeParquetRecord(rec ndntdump.Record) (pr parquetRecord) {
+	pr = parquetRecord{
+		DirType:      rec.DirType,
+		Timestamp:    rec.Timestamp,
+		Face:         rec.Face,
+		Flow:         rec.Flow,
+		FlowID:       rec.FlowID,
+		Size2:        rec.Size2,
+		Diag:         string(rec.Diag),
+		DiagError:    rec.DiagError,
+		LpSeq:        rec.LpSeq,
+		FragIndex:    rec.FragIndex,
+		FragCount:    rec.FragCount,
+		PitToken:     rec.PitToken,
+		CongMark:     rec.CongMark,
+		TxSeq:        rec.TxSeq,
+		Acks:         rec.Acks,
+		NextHopFace:  rec.NextHopFace,
+		IncomingFace: rec.IncomingFace,
+		CachePolicy:  rec.CachePolicy,
+		Size3:        rec.Size3,
+		NackReason:   rec.NackReason,
+		Name:         nameString(rec.Name),
+		NameLen:      rec.NameLen,
+		NameSize:     rec.NameSize,
+		Prefix:       nameString(rec.Prefix),
+		CanBePrefix:  rec.CanBePrefix,
+		MustBeFresh:  rec.MustBeFresh,
+		Lifetime:     rec.Lifetime,
+		HopLimit:     rec.HopLimit,
+		Nonce:        rec.Nonce,
+		AppParams:    rec.AppParams,
+		AppParamsLen: rec.AppParamsLen,
+		ParamsDigest: rec.ParamsDigest,
+		SigNonce:     rec.SigNonce,
+		SigTime:      rec.SigTime,
+		SigSeqNum:    rec.SigSeqNum,
+		SigType:      rec.SigType,
+		KeyLocator:   nameString(rec.KeyLocator),
+		KeyDigest:    rec.KeyDigest,
+		SigValueLen:  rec.SigValueLen,
+		ContentType:  rec.ContentType,
+		Freshness:    rec.Freshness,
+		FinalBlock:   rec.FinalBlock,
+		ContentLen:   rec.ContentLen,
+		Digest:       rec.Digest,
+		Proto:        rec.Proto,
+		ProtoOp:      rec.ProtoOp,
+		SyncGroup:    nameString(rec.SyncGroup),
+		IBFSize:      rec.IBFSize,
+	}
+
+	if fk := rec.FlowInfo; fk != nil {
+		pfk := parquetFlowKey(*fk)
+		pr.FlowInfo = &pfk
+	}
+	for _, nc := range rec.NameComps {
+		pr.NameComps = append(pr.NameComps, parquetNameComp(nc))
+	}
+	for _, fh := range rec.FwHint {
+		pr.FwHint = append(pr.FwHint, nameString(fh))
+	}
+	if cp := rec.ControlParams; cp != nil {
+		pr.ControlParams = &parquetControlParameters{
+			Name:            nameString(cp.Name),
+			FaceID:          cp.FaceID,
+			URI:             cp.URI,
+			LocalURI:        cp.LocalURI,
+			Origin:          cp.Origin,
+			Cost:            cp.Cost,
+			Capacity:        cp.Capacity,
+			Count:           cp.Count,
+			Flags:           cp.Flags,
+			Mask:            cp.Mask,
+			Strategy:        nameString(cp.Strategy),
+			Expiration:      cp.Expiration,
+			FacePersistency: cp.FacePersistency,
+			Mtu:             cp.Mtu,
+		}
+	}
+	for _, ent := range rec.StateVector {
+		pr.StateVector = append(pr.StateVector, parquetSVEntry{
+			Node: nameString(ent.Node),
+			Seq:  ent.Seq,
+			Boot: ent.Boot,
+		})
+	}
+	return pr
+}
@@ -0,0 +1,124 @@
+package fileoutput_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/fileoutput"
+)
+
+type parquetTestRow struct {
+	DirType   string   `parquet:"t"`
+	Timestamp int64    `parquet:"ts"`
+	Name      *string  `parquet:"name,optional"`
+	Size2     int      `parquet:"size2"`
+	FwHint    []string `parquet:"fwHint,list"`
+	FlowInfo  *struct {
+		LocalPort int `parquet:"localPort,optional"`
+	} `parquet:"flowInfo,optional"`
+}
+
+func TestParquetOutput(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	filename := filepath.Join(t.TempDir(), "records.parquet")
+	o, e := fileoutput.Open(filename, "", fileoutput.Options{
+		Header:  ndntdump.Header{Tool: "test"},
+		Parquet: fileoutput.ParquetOptions{RowGroupRows: 2},
+	})
+	require.NoError(e)
+	require.NoError(o.Write(ndntdump.Record{
+		DirType:   ">I",
+		Timestamp: 1700000000_000000001,
+		Size2:     10,
+		Name:      ndn.ParseName("/A"),
+		FwHint:    []ndn.Name{ndn.ParseName("/F")},
+		FlowInfo:  &ndntdump.FlowKey{Transport: ndntdump.TransportUDP, LocalPort: 6363},
+	}))
+	require.NoError(o.Write(ndntdump.Record{}))
+	require.NoError(o.Write(ndntdump.Record{DirType: "<D", Timestamp: 1700000000_000000002, Size2: 20}))
+	require.NoError(o.Write(ndntdump.Record{DirType: "!", Timestamp: 1700000000_000000003, Diag: ndntdump.DiagTLV}))
+	require.NoError(o.Close())
+
+	f, e := os.Open(filename)
+	require.NoError(e)
+	defer f.Close()
+	st, e := f.Stat()
+	require.NoError(e)
+	pf, e := parquet.OpenFile(f, st.Size())
+	require.NoError(e)
+	assert.Len(pf.RowGroups(), 2)
+
+	hdrJSON, ok := pf.Lookup(fileoutput.ParquetHeaderKey)
+	require.True(ok)
+	var hdr ndntdump.Header
+	require.NoError(json.Unmarshal([]byte(hdrJSON), &hdr))
+	assert.Equal(ndntdump.SchemaVersion, hdr.Schema)
+	assert.Equal("test", hdr.Tool)
+
+	rows := make([]parquetTestRow, 4)
+	n, _ := parquet.NewGenericReader[parquetTestRow](pf).Read(rows)
+	require.Equal(3, n)
+	assert.Equal(">I", rows[0].DirType)
+	assert.Equal(int64(1700000000_000000001), rows[0].Timestamp)
+	require.NotNil(rows[0].Name)
+	assert.Equal("/8=A", *rows[0].Name)
+	assert.Equal([]string{"/8=F"}, rows[0].FwHint)
+	require.NotNil(rows[0].FlowInfo)
+	assert.Equal(6363, rows[0].FlowInfo.LocalPort)
+	assert.Equal("<D", rows[1].DirType)
+	assert.Nil(rows[1].Name)
+	assert.Nil(rows[1].FlowInfo)
+	assert.Equal(20, rows[1].Size2)
+	assert.Equal("!", rows[2].DirType)
+}
+
+func TestParquetRowGroupBytes(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	filename := filepath.Join(t.TempDir(), "records.parquet")
+	o, e := fileoutput.NewParquetOutput(filename, ndntdump.Header{}, fileoutput.ParquetOptions{RowGroupBytes: 4096})
+	require.NoError(e)
+	for i := range 100 {
+		require.NoError(o.Write(ndntdump.Record{
+			DirType:   ">I",
+			Timestamp: int64(i),
+			Name:      ndn.ParseName("/" + strings.Repeat("A", 100)),
+		}))
+	}
+	require.NoError(o.Close())
+
+	f, e := os.Open(filename)
+	require.NoError(e)
+	defer f.Close()
+	st, e := f.Stat()
+	require.NoError(e)
+	pf, e := parquet.OpenFile(f, st.Size())
+	require.NoError(e)
+	assert.Greater(len(pf.RowGroups()), 1)
+	assert.Less(len(pf.RowGroups()), 100)
+	assert.EqualValues(100, pf.NumRows())
+}
+
+func TestParquetCompressedExt(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	for _, filename := range []string{"records.parquet.gz", "records.parquet.zst", "records.sqlite.gz", "records.db.zst"} {
+		_, e := fileoutput.Open(filepath.Join(dir, filename), "", fileoutput.Options{})
+		assert.Error(e, filename)
+		assert.NoFileExists(filepath.Join(dir, filename))
+	}
+	_, e := fileoutput.NewParquetOutput(filepath.Join(dir, "records.parquet.gz"), ndntdump.Header{}, fileoutput.ParquetOptions{})
+	assert.Error(e)
+	_, e = fileoutput.NewSqliteOutput(filepath.Join(dir, "records.sqlite.gz"), ndntdump.Header{}, fileoutput.SqliteOptions{})
+	assert.Error(e)
+}
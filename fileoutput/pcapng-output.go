@@ -2,16 +2,33 @@ package fileoutput
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/gopacket/gopacket/layers"
 	"github.com/gopacket/gopacket/pcapgo"
 	"github.com/usnistgov/ndntdump"
 )
 
+type pcapngIntfKey struct {
+	LinkType layers.LinkType
+	Face     int
+}
+
+// PcapngOptions contains PcapngOutput options.
+type PcapngOptions struct {
+	// Snap truncates each packet after NDN name and metadata, as indicated by Record.SnapLen.
+	// The original packet length is retained, so that the packet appears truncated by a snap length.
+	Snap bool
+}
+
 // PcapngOutput saves packet bytes in pcapng file.
+// Ethernet packets without NDN-DPDK face ID are written to interface 0.
+// Other link types and NDN-DPDK faces are written to additional interfaces.
 type PcapngOutput struct {
-	cf  *compressedFile
-	ngw *pcapgo.NgWriter
+	cf    *compressedFile
+	ngw   *pcapgo.NgWriter
+	intfs map[pcapngIntfKey]int
+	opts  PcapngOptions
 }
 
 func (o *PcapngOutput) Close() error {
@@ -21,21 +38,61 @@ func (o *PcapngOutput) Close() error {
 	)
 }
 
+// Flush writes buffered blocks to the underlying file.
+func (o *PcapngOutput) Flush() error {
+	return o.ngw.Flush()
+}
+
 func (o *PcapngOutput) Write(rec ndntdump.Record) error {
 	if len(rec.Wire) == 0 {
 		return nil
 	}
-	rec.CaptureInfo.InterfaceIndex = 0
+
+	key := pcapngIntfKey{LinkType: rec.LinkType, Face: rec.Face}
+	if key.LinkType == 0 {
+		key.LinkType = layers.LinkTypeEthernet
+	}
+	intf, ok := o.intfs[key]
+	if !ok {
+		ngi := pcapgo.DefaultNgInterface
+		ngi.Name = fmt.Sprintf("intf%d", len(o.intfs))
+		if key.Face != 0 {
+			ngi.Name = fmt.Sprintf("face%d", key.Face)
+		}
+		ngi.LinkType = key.LinkType
+		var e error
+		if intf, e = o.ngw.AddInterface(ngi); e != nil {
+			return e
+		}
+		o.intfs[key] = intf
+	}
+
+	rec.CaptureInfo.InterfaceIndex = intf
 	rec.CaptureInfo.AncillaryData = nil
+	if o.opts.Snap && rec.SnapLen > 0 && rec.SnapLen < len(rec.Wire) {
+		rec.Wire = rec.Wire[:rec.SnapLen]
+	}
+	rec.CaptureInfo.CaptureLength = len(rec.Wire)
 	return o.ngw.WritePacket(rec.CaptureInfo, rec.Wire)
 }
 
 // NewPcapngOutput creates PcapngOutput.
-func NewPcapngOutput(filename string) (o *PcapngOutput, e error) {
-	o = &PcapngOutput{}
-	if o.cf, e = newCompressedFile(filename); e != nil {
+func NewPcapngOutput(filename string, opts PcapngOptions) (o *PcapngOutput, e error) {
+	cf, e := newCompressedFile(filename)
+	if e != nil {
 		return nil, e
 	}
+	return newPcapngOutput(cf, opts)
+}
+
+func newPcapngOutput(cf *compressedFile, opts PcapngOptions) (o *PcapngOutput, e error) {
+	o = &PcapngOutput{
+		cf:   cf,
+		opts: opts,
+		intfs: map[pcapngIntfKey]int{
+			{LinkType: layers.LinkTypeEthernet}: 0,
+		},
+	}
 	if o.ngw, e = pcapgo.NewNgWriter(o.cf, layers.LinkTypeEthernet); e != nil {
 		o.cf.Close()
 		return nil, e
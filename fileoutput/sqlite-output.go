@@ -0,0 +1,360 @@
+package fileoutput
+
+import (
+	"database/sql"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+	_ "modernc.org/sqlite" // SQLite driver
+)
+
+// SqliteOptions contains SqliteOutput options.
+type SqliteOptions struct {
+	// BatchSize is the number of records written in each transaction.
+	// Default is 10000.
+	BatchSize int
+}
+
+func (opts *SqliteOptions) applyDefaults() {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 10000
+	}
+}
+
+// sqliteCacheSize is the maximum number of cached name and flow IDs.
+const sqliteCacheSize = 1 << 16
+
+// sqliteColumn describes a column in packets table.
+type sqliteColumn struct {
+	name  string
+	typ   string
+	index int
+}
+
+// sqliteSpecialColumns are Record fields stored in names and flows tables.
+var sqliteSpecialColumns = map[string]bool{
+	"name":     true,
+	"prefix":   true,
+	"flow":     true,
+	"flowId":   true,
+	"flowInfo": true,
+}
+
+// sqlitePacketColumns lists packets table columns that are directly mapped from Record fields.
+var sqlitePacketColumns = sync.OnceValue(func() (columns []sqliteColumn) {
+	typ := reflect.TypeFor[ndntdump.Record]()
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" || sqliteSpecialColumns[name] {
+			continue
+		}
+
+		col := sqliteColumn{name: name, typ: "TEXT", index: i}
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Bool:
+			col.typ = "INTEGER"
+		case reflect.Slice:
+			if f.Type.Elem().Kind() == reflect.Uint8 {
+				col.typ = "BLOB"
+			}
+		}
+		columns = append(columns, col)
+	}
+	return columns
+})
+
+// sqliteValue converts a field value to SQLite value.
+// Zero values are stored as NULL, names are stored as URI, and complex values are stored as JSON.
+// Unsigned integers are stored as signed 64-bit integers with the same bits.
+func sqliteValue(v reflect.Value) any {
+	if v.IsZero() {
+		return nil
+	}
+	switch x := v.Interface().(type) {
+	case []byte:
+		return x
+	case encoding.TextMarshaler:
+		text, _ := x.MarshalText()
+		return string(text)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Bool:
+		return 1
+	}
+	j, _ := json.Marshal(v.Interface())
+	return string(j)
+}
+
+func sqliteSchema() string {
+	var b strings.Builder
+	b.WriteString(`
+CREATE TABLE meta (
+  key TEXT PRIMARY KEY,
+  value TEXT NOT NULL
+);
+CREATE TABLE names (
+  id INTEGER PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE
+);
+CREATE TABLE flows (
+  id INTEGER PRIMARY KEY,
+  flow BLOB NOT NULL UNIQUE,
+  flowId TEXT,
+  transport TEXT,
+  websocket INTEGER,
+  localMAC TEXT,
+  remoteMAC TEXT,
+  localIP TEXT,
+  remoteIP TEXT,
+  localPort INTEGER,
+  remotePort INTEGER
+);
+CREATE TABLE packets (
+  id INTEGER PRIMARY KEY,
+  flow INTEGER REFERENCES flows (id),
+  name INTEGER REFERENCES names (id),
+  prefix INTEGER REFERENCES names (id)`)
+	for _, col := range sqlitePacketColumns() {
+		fmt.Fprintf(&b, ",\n  %q %s", col.name, col.typ)
+	}
+	b.WriteString(`
+);
+CREATE INDEX packets_ts ON packets (ts);
+CREATE INDEX packets_flow ON packets (flow);
+CREATE INDEX packets_name ON packets (name);
+CREATE INDEX packets_prefix ON packets (prefix);
+`)
+	return b.String()
+}
+
+func sqliteInsertPacket() string {
+	names, params := []string{"flow", "name", "prefix"}, []string{"?", "?", "?"}
+	for _, col := range sqlitePacketColumns() {
+		names, params = append(names, fmt.Sprintf("%q", col.name)), append(params, "?")
+	}
+	return "INSERT INTO packets (" + strings.Join(names, ",") + ") VALUES (" + strings.Join(params, ",") + ")"
+}
+
+const (
+	sqliteInsertName = `INSERT INTO names (name) VALUES (?) ON CONFLICT (name) DO UPDATE SET name=excluded.name RETURNING id`
+	sqliteInsertFlow = `INSERT INTO flows (flow,flowId,transport,websocket,localMAC,remoteMAC,localIP,remoteIP,localPort,remotePort) VALUES (?,?,?,?,?,?,?,?,?,?)
+ON CONFLICT (flow) DO UPDATE SET flow=excluded.flow RETURNING id`
+)
+
+// SqliteOutput saves packet information in SQLite database.
+//
+// The database contains these tables:
+//   - meta: key-value pairs, where "header" is the records file header.
+//   - names: distinct packet names and name prefixes.
+//   - flows: distinct flow keys, in both raw and structured forms.
+//   - packets: records, in which name, prefix, and flow columns refer to other tables.
+type SqliteOutput struct {
+	filename string
+	file     os.FileInfo
+	db       *sql.DB
+	opts     SqliteOptions
+	stmts    [3]*sql.Stmt
+	tx       *sql.Tx
+	txStmts  [3]*sql.Stmt
+	pending  int
+	names    map[string]int64
+	flows    map[string]int64
+	args     []any
+}
+
+func (o *SqliteOutput) Close() error {
+	errs := []error{o.commit()}
+	for _, stmt := range o.stmts {
+		errs = append(errs, stmt.Close())
+	}
+	errs = append(errs, o.db.Close())
+	errs = append(errs, o.checkMoved())
+	return errors.Join(errs...)
+}
+
+// checkMoved handles a database file that was renamed while open, such as by logrotate.
+// SQLite cannot checkpoint such a database, so that recent records remain in the write-ahead log
+// at the original path, which would be deleted when a new database is created there.
+// The write-ahead log is renamed aside, and can be recovered by placing it next to the moved database.
+func (o *SqliteOutput) checkMoved() error {
+	if st, e := os.Stat(o.filename); e == nil && os.SameFile(st, o.file) {
+		return nil
+	}
+	wal := o.filename + "-wal"
+	if st, e := os.Stat(wal); e != nil || st.Size() == 0 {
+		return nil
+	}
+	aside := wal + "." + time.Now().Format("20060102T150405.000000000")
+	if e := os.Rename(wal, aside); e != nil {
+		return e
+	}
+	return fmt.Errorf("%s was moved while open, rename %s to its path plus -wal suffix to recover recent records", o.filename, aside)
+}
+
+func (o *SqliteOutput) Write(rec ndntdump.Record) (e error) {
+	if len(rec.DirType) == 0 {
+		return nil
+	}
+
+	if o.tx == nil {
+		if o.tx, e = o.db.Begin(); e != nil {
+			return e
+		}
+		for i, stmt := range o.stmts {
+			o.txStmts[i] = o.tx.Stmt(stmt)
+		}
+	}
+
+	args := o.args[:0]
+	flow, e := o.flowID(rec)
+	if e != nil {
+		return e
+	}
+	name, e := o.nameID(rec.Name)
+	if e != nil {
+		return e
+	}
+	prefix, e := o.nameID(rec.Prefix)
+	if e != nil {
+		return e
+	}
+	args = append(args, flow, name, prefix)
+
+	v := reflect.ValueOf(rec)
+	for _, col := range sqlitePacketColumns() {
+		args = append(args, sqliteValue(v.Field(col.index)))
+	}
+	o.args = args
+	if _, e = o.txStmts[0].Exec(args...); e != nil {
+		return e
+	}
+
+	if o.pending++; o.pending >= o.opts.BatchSize {
+		return o.commit()
+	}
+	return nil
+}
+
+func (o *SqliteOutput) commit() error {
+	if o.tx == nil {
+		return nil
+	}
+	tx := o.tx
+	o.tx, o.pending = nil, 0
+	return tx.Commit()
+}
+
+func (o *SqliteOutput) nameID(name ndn.Name) (id any, e error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+	uri := name.String()
+	return o.lookup(o.names, uri, func() *sql.Row {
+		return o.txStmts[1].QueryRow(uri)
+	})
+}
+
+func (o *SqliteOutput) flowID(rec ndntdump.Record) (id any, e error) {
+	if len(rec.Flow) == 0 {
+		return nil, nil
+	}
+	return o.lookup(o.flows, string(rec.Flow), func() *sql.Row {
+		fk, _ := rec.FlowKey()
+		return o.txStmts[2].QueryRow(rec.Flow, ndntdump.FlowID(rec.Flow), fk.Transport, fk.WebSocket,
+			fk.LocalMAC, fk.RemoteMAC, fk.LocalIP, fk.RemoteIP, fk.LocalPort, fk.RemotePort)
+	})
+}
+
+func (o *SqliteOutput) lookup(cache map[string]int64, key string, insert func() *sql.Row) (id any, e error) {
+	if id, ok := cache[key]; ok {
+		return id, nil
+	}
+	var n int64
+	if e = insert().Scan(&n); e != nil {
+		return nil, e
+	}
+	if len(cache) >= sqliteCacheSize {
+		clear(cache)
+	}
+	cache[key] = n
+	return n, nil
+}
+
+// NewSqliteOutput creates SqliteOutput.
+// If the file exists, it is replaced with a new database.
+// hdr is saved in meta table, with schema version and start time filled in.
+func NewSqliteOutput(filename string, hdr ndntdump.Header, opts SqliteOptions) (o *SqliteOutput, e error) {
+	opts.applyDefaults()
+	if e = checkUncompressed(FormatSqlite, filename); e != nil {
+		return nil, e
+	}
+
+	hdr.Type, hdr.Schema, hdr.Start = ndntdump.DirTypeHeader, ndntdump.SchemaVersion, time.Now()
+	hdrJSON, e := json.Marshal(hdr)
+	if e != nil {
+		return nil, e
+	}
+
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if e = os.Remove(filename + suffix); e != nil && !errors.Is(e, os.ErrNotExist) {
+			return nil, e
+		}
+	}
+
+	o = &SqliteOutput{
+		filename: filename,
+		opts:     opts,
+		names:    map[string]int64{},
+		flows:    map[string]int64{},
+	}
+	if o.db, e = sql.Open("sqlite", filename); e != nil {
+		return nil, e
+	}
+	o.db.SetMaxOpenConns(1)
+
+	if e = o.init(string(hdrJSON)); e != nil {
+		o.db.Close()
+		return nil, e
+	}
+	if o.file, e = os.Stat(filename); e != nil {
+		o.db.Close()
+		return nil, e
+	}
+	return o, nil
+}
+
+func (o *SqliteOutput) init(hdrJSON string) (e error) {
+	if _, e = o.db.Exec("PRAGMA journal_mode=WAL; PRAGMA synchronous=NORMAL;"); e != nil {
+		return e
+	}
+	if _, e = o.db.Exec(sqliteSchema()); e != nil {
+		return e
+	}
+	if _, e = o.db.Exec("INSERT INTO meta (key, value) VALUES ('header', ?)", hdrJSON); e != nil {
+		return e
+	}
+
+	for i, query := range []string{sqliteInsertPacket(), sqliteInsertName, sqliteInsertFlow} {
+		if o.stmts[i], e = o.db.Prepare(query); e != nil {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package fileoutput_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/fileoutput"
+)
+
+func TestSqliteOutput(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	filename := filepath.Join(t.TempDir(), "records.sqlite")
+	o, e := fileoutput.Open(filename, "", fileoutput.Options{
+		Header: ndntdump.Header{Tool: "test"},
+		Sqlite: fileoutput.SqliteOptions{BatchSize: 2},
+	})
+	require.NoError(e)
+	flow := []byte{192, 0, 2, 1, 192, 0, 2, 2, 17, 0x18, 0xDB, 0x18, 0xDC}
+	for i, rec := range []ndntdump.Record{
+		{DirType: ">I", Timestamp: 1, Flow: flow, Size2: 10, Name: ndn.ParseName("/A/1"), Prefix: ndn.ParseName("/A"), MustBeFresh: true},
+		{},
+		{DirType: "<D", Timestamp: 2, Flow: flow, Size2: 20, Name: ndn.ParseName("/A/1"), Prefix: ndn.ParseName("/A"), LpSeq: 1 << 63},
+		{DirType: ">I", Timestamp: 3, Flow: []byte{2, 0, 0, 0, 0, 1, 2, 0, 0, 0, 0, 2}, Name: ndn.ParseName("/B"), Acks: []uint64{1, 2}},
+		{DirType: "!", Timestamp: 4, Diag: ndntdump.DiagTLV},
+	} {
+		require.NoError(o.Write(rec), i)
+	}
+	require.NoError(o.Close())
+
+	db, e := sql.Open("sqlite", filename)
+	require.NoError(e)
+	defer db.Close()
+
+	var hdrJSON string
+	require.NoError(db.QueryRow("SELECT value FROM meta WHERE key='header'").Scan(&hdrJSON))
+	var hdr ndntdump.Header
+	require.NoError(json.Unmarshal([]byte(hdrJSON), &hdr))
+	assert.Equal(ndntdump.SchemaVersion, hdr.Schema)
+	assert.Equal("test", hdr.Tool)
+
+	var nPackets, nNames, nFlows int
+	require.NoError(db.QueryRow("SELECT (SELECT COUNT(*) FROM packets), (SELECT COUNT(*) FROM names), (SELECT COUNT(*) FROM flows)").Scan(&nPackets, &nNames, &nFlows))
+	assert.Equal(4, nPackets)
+	assert.Equal(3, nNames)
+	assert.Equal(2, nFlows)
+
+	rows, e := db.Query(`SELECT p.t, p.size2, f.remotePort, n.name, p.mbf, p.acks, p.lpSeq FROM packets p
+		JOIN flows f ON p.flow = f.id JOIN names pn ON p.prefix = pn.id JOIN names n ON p.name = n.id
+		WHERE pn.name = '/8=A' ORDER BY p.ts`)
+	require.NoError(e)
+	defer rows.Close()
+	type row struct {
+		T     string
+		Size2 int
+		Port  int
+		Name  string
+		Mbf   sql.NullBool
+		Acks  sql.NullString
+		LpSeq sql.NullInt64
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		require.NoError(rows.Scan(&r.T, &r.Size2, &r.Port, &r.Name, &r.Mbf, &r.Acks, &r.LpSeq))
+		got = append(got, r)
+	}
+	require.NoError(rows.Err())
+	require.Len(got, 2)
+	assert.Equal(">I", got[0].T)
+	assert.Equal(6364, got[0].Port)
+	assert.Equal("/8=A/8=1", got[0].Name)
+	assert.True(got[0].Mbf.Bool)
+	assert.Equal("<D", got[1].T)
+	assert.Equal(20, got[1].Size2)
+	assert.False(got[1].Mbf.Valid)
+	assert.Equal(uint64(1<<63), uint64(got[1].LpSeq.Int64))
+
+	var acks, diag string
+	require.NoError(db.QueryRow("SELECT acks FROM packets WHERE ts=3").Scan(&acks))
+	assert.Equal("[1,2]", acks)
+	require.NoError(db.QueryRow("SELECT diag FROM packets WHERE name IS NULL").Scan(&diag))
+	assert.Equal("tlv", diag)
+}
+
+func TestSqliteOutputReplace(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	filename := filepath.Join(t.TempDir(), "records.db")
+	for i := range 2 {
+		o, e := fileoutput.NewSqliteOutput(filename, ndntdump.Header{}, fileoutput.SqliteOptions{})
+		require.NoError(e)
+		if i == 0 {
+			require.NoError(o.Write(ndntdump.Record{DirType: ">I", Name: ndn.ParseName("/A")}))
+		}
+		require.NoError(o.Close())
+	}
+
+	db, e := sql.Open("sqlite", filename)
+	require.NoError(e)
+	defer db.Close()
+	var n int
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM packets").Scan(&n))
+	assert.Equal(0, n)
+}
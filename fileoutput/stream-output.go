@@ -0,0 +1,255 @@
+package fileoutput
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/usnistgov/ndntdump"
+)
+
+// StdoutFilename is the filename that refers to standard output.
+const StdoutFilename = "-"
+
+// streamNetworks lists listener address prefixes, such as "unix:/run/ndntdump.sock" and "tcp:127.0.0.1:6380".
+var streamNetworks = []string{"unix", "tcp", "tcp4", "tcp6"}
+
+// ParseStreamAddr determines whether a filename refers to a stream listener.
+func ParseStreamAddr(filename string) (network, addr string, ok bool) {
+	network, addr, ok = strings.Cut(filename, ":")
+	if !ok || addr == "" {
+		return "", "", false
+	}
+	for _, n := range streamNetworks {
+		if network == n {
+			return network, addr, true
+		}
+	}
+	return "", "", false
+}
+
+// StreamOptions contains StreamOutput options.
+type StreamOptions struct {
+	// Buffer is the maximum number of records queued for each subscriber.
+	// When a subscriber's queue is full, further records are dropped for that subscriber.
+	// Default is 1024.
+	Buffer int
+}
+
+func (opts *StreamOptions) applyDefaults() {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 1024
+	}
+}
+
+// streamCloseTimeout is the maximum duration for sending queued records to subscribers upon Close.
+const streamCloseTimeout = time.Second
+
+// flusher is a RecordOutput with internal buffering.
+type flusher interface {
+	Flush() error
+}
+
+// streamSubscriber is a connected consumer of StreamOutput.
+type streamSubscriber struct {
+	conn   net.Conn
+	output ndntdump.RecordOutput
+	buf    bytes.Buffer
+	queue  chan []byte
+}
+
+// encode writes a record, or flushes the output if rec is nil, and returns encoded octets.
+func (sub *streamSubscriber) encode(rec *ndntdump.Record) ([]byte, error) {
+	var e error
+	if rec != nil {
+		e = sub.output.Write(*rec)
+	}
+	if f, ok := sub.output.(flusher); ok && e == nil {
+		e = f.Flush()
+	}
+	chunk := bytes.Clone(sub.buf.Bytes())
+	sub.buf.Reset()
+	return chunk, e
+}
+
+// StreamOutput sends records to subscribers connected to a Unix or TCP listener.
+//
+// Each subscriber receives a separate stream that starts with the file header, as if it were a file.
+// Records are encoded synchronously and queued for each subscriber.
+// A slow subscriber loses records when its queue is full, but does not stall the capture.
+type StreamOutput struct {
+	ln      net.Listener
+	opts    StreamOptions
+	create  func(w io.Writer) (ndntdump.RecordOutput, error)
+	mu      sync.Mutex
+	subs    map[*streamSubscriber]bool
+	closing bool
+	wg      sync.WaitGroup
+	dropped atomic.Uint64
+}
+
+// Addr returns the listener address.
+func (o *StreamOutput) Addr() net.Addr {
+	return o.ln.Addr()
+}
+
+// Subscribers returns the number of connected subscribers.
+func (o *StreamOutput) Subscribers() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.subs)
+}
+
+// Dropped returns the number of records dropped due to full subscriber queues.
+func (o *StreamOutput) Dropped() uint64 {
+	return o.dropped.Load()
+}
+
+func (o *StreamOutput) Close() error {
+	e := o.ln.Close()
+
+	o.mu.Lock()
+	o.closing = true
+	deadline := time.Now().Add(streamCloseTimeout)
+	for sub := range o.subs {
+		sub.conn.SetWriteDeadline(deadline)
+		o.remove(sub)
+	}
+	o.mu.Unlock()
+
+	o.wg.Wait()
+	return e
+}
+
+func (o *StreamOutput) Write(rec ndntdump.Record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for sub := range o.subs {
+		if len(sub.queue) == cap(sub.queue) {
+			o.dropped.Add(1)
+			continue
+		}
+		chunk, e := sub.encode(&rec)
+		if e != nil {
+			o.remove(sub)
+			continue
+		}
+		if len(chunk) > 0 {
+			sub.queue <- chunk
+		}
+	}
+	return nil
+}
+
+// remove closes a subscriber's output and queue.
+// Remaining queued octets are sent by the subscriber's goroutine.
+// o.mu must be held.
+func (o *StreamOutput) remove(sub *streamSubscriber) {
+	if !o.subs[sub] {
+		return
+	}
+	delete(o.subs, sub)
+
+	sub.output.Close()
+	if chunk, _ := sub.encode(nil); len(chunk) > 0 && len(sub.queue) < cap(sub.queue) {
+		sub.queue <- chunk
+	}
+	close(sub.queue)
+}
+
+func (o *StreamOutput) accept() {
+	defer o.wg.Done()
+	for {
+		conn, e := o.ln.Accept()
+		if e != nil {
+			return
+		}
+
+		sub := &streamSubscriber{
+			conn:  conn,
+			queue: make(chan []byte, o.opts.Buffer+1),
+		}
+		o.mu.Lock()
+		if o.closing {
+			o.mu.Unlock()
+			conn.Close()
+			return
+		}
+		if sub.output, e = o.create(&sub.buf); e != nil {
+			o.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		header, _ := sub.encode(nil)
+		sub.queue <- header
+		o.subs[sub] = true
+		o.wg.Add(1)
+		o.mu.Unlock()
+
+		go o.send(sub)
+	}
+}
+
+// send transmits queued octets to a subscriber.
+// If the connection fails, the subscriber is removed.
+func (o *StreamOutput) send(sub *streamSubscriber) {
+	defer o.wg.Done()
+	defer sub.conn.Close()
+	ok := true
+	for chunk := range sub.queue {
+		if !ok {
+			continue
+		}
+		if _, e := sub.conn.Write(chunk); e != nil {
+			ok = false
+			o.mu.Lock()
+			o.remove(sub)
+			o.mu.Unlock()
+		}
+	}
+}
+
+// removeSocket deletes a stale Unix socket file.
+func removeSocket(addr string) error {
+	st, e := os.Lstat(addr)
+	switch {
+	case errors.Is(e, os.ErrNotExist):
+		return nil
+	case e != nil:
+		return e
+	case st.Mode()&os.ModeSocket == 0:
+		return fmt.Errorf("%s exists and is not a socket", addr)
+	}
+	return os.Remove(addr)
+}
+
+// NewStreamOutput creates StreamOutput listening on a Unix or TCP address.
+// create is invoked for each subscriber to encode records into w.
+// An existing Unix socket file is replaced, but any other existing file is an error.
+func NewStreamOutput(network, addr string, opts StreamOptions, create func(w io.Writer) (ndntdump.RecordOutput, error)) (o *StreamOutput, e error) {
+	opts.applyDefaults()
+	if network == "unix" {
+		if e = removeSocket(addr); e != nil {
+			return nil, e
+		}
+	}
+
+	o = &StreamOutput{
+		opts:   opts,
+		create: create,
+		subs:   map[*streamSubscriber]bool{},
+	}
+	if o.ln, e = net.Listen(network, addr); e != nil {
+		return nil, e
+	}
+	o.wg.Add(1)
+	go o.accept()
+	return o, nil
+}
@@ -0,0 +1,146 @@
+package fileoutput_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/fileoutput"
+)
+
+// lineOutput writes each record's DirType on a line.
+type lineOutput struct {
+	w io.Writer
+}
+
+func (o lineOutput) Close() error {
+	_, e := fmt.Fprintln(o.w, "E")
+	return e
+}
+
+func (o lineOutput) Write(rec ndntdump.Record) error {
+	_, e := fmt.Fprintln(o.w, rec.DirType)
+	return e
+}
+
+func newLineOutput(w io.Writer) (ndntdump.RecordOutput, error) {
+	_, e := fmt.Fprintln(w, "H")
+	return lineOutput{w}, e
+}
+
+func TestStreamOutput(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	sock := filepath.Join(t.TempDir(), "records.sock")
+	o, e := fileoutput.NewStreamOutput("unix", sock, fileoutput.StreamOptions{}, newLineOutput)
+	require.NoError(e)
+
+	c1, e := net.Dial("unix", sock)
+	require.NoError(e)
+	defer c1.Close()
+	require.Eventually(func() bool { return o.Subscribers() == 1 }, time.Second, time.Millisecond)
+	for range 3 {
+		require.NoError(o.Write(ndntdump.Record{DirType: ">I"}))
+	}
+
+	c2, e := net.Dial("unix", sock)
+	require.NoError(e)
+	defer c2.Close()
+	require.Eventually(func() bool { return o.Subscribers() == 2 }, time.Second, time.Millisecond)
+	require.NoError(o.Write(ndntdump.Record{DirType: "<D"}))
+	require.NoError(o.Close())
+
+	b1, e := io.ReadAll(c1)
+	require.NoError(e)
+	assert.Equal("H\n>I\n>I\n>I\n<D\nE\n", string(b1))
+	b2, e := io.ReadAll(c2)
+	require.NoError(e)
+	assert.Equal("H\n<D\nE\n", string(b2))
+	assert.Zero(o.Dropped())
+}
+
+func TestStreamOutputExisting(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+	dir := t.TempDir()
+
+	// a regular file is not replaced
+	data := filepath.Join(dir, "data")
+	require.NoError(os.WriteFile(data, []byte("records"), 0o644))
+	_, e := fileoutput.NewStreamOutput("unix", data, fileoutput.StreamOptions{}, newLineOutput)
+	assert.Error(e)
+	content, e := os.ReadFile(data)
+	require.NoError(e)
+	assert.Equal("records", string(content))
+
+	// a stale socket is replaced
+	sock := filepath.Join(dir, "records.sock")
+	ln, e := net.ListenUnix("unix", &net.UnixAddr{Net: "unix", Name: sock})
+	require.NoError(e)
+	ln.SetUnlinkOnClose(false)
+	ln.Close()
+	o, e := fileoutput.NewStreamOutput("unix", sock, fileoutput.StreamOptions{}, newLineOutput)
+	require.NoError(e)
+	require.NoError(o.Close())
+}
+
+func TestStreamOutputSlow(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	o, e := fileoutput.NewStreamOutput("tcp", "127.0.0.1:0", fileoutput.StreamOptions{Buffer: 4}, newLineOutput)
+	require.NoError(e)
+
+	c, e := net.Dial("tcp", o.Addr().String())
+	require.NoError(e)
+	defer c.Close()
+	require.Eventually(func() bool { return o.Subscribers() == 1 }, time.Second, time.Millisecond)
+
+	// subscriber does not read, so that socket buffers and the queue fill up
+	rec := ndntdump.Record{DirType: strings.Repeat("X", 4096)}
+	for range 4096 {
+		require.NoError(o.Write(rec))
+	}
+	assert.Greater(o.Dropped(), uint64(0))
+	require.NoError(o.Close())
+}
+
+func TestOpenStream(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	sock := filepath.Join(t.TempDir(), "records.sock")
+	o, e := fileoutput.Open("unix:"+sock, "", fileoutput.Options{RecordsFormat: fileoutput.FormatCsv})
+	require.NoError(e)
+
+	c, e := net.Dial("unix", sock)
+	require.NoError(e)
+	defer c.Close()
+
+	// the header is sent when the subscriber is added, so that subsequent records are delivered
+	br := bufio.NewReader(c)
+	header, e := br.ReadString('\n')
+	require.NoError(e)
+	assert.Equal("t,ts,face,flowId,size2,size3,name,nackReason,lifetime,hopLimit,contentType,freshness,contentLen\n", header)
+	require.NoError(o.Write(ndntdump.Record{DirType: ">I", Timestamp: 1}))
+	require.NoError(o.Close())
+
+	b, e := io.ReadAll(br)
+	require.NoError(e)
+	assert.Equal(">I,1,,,,,,,,,,,\n", string(b))
+
+	for _, format := range []string{fileoutput.FormatSqlite, fileoutput.FormatParquet} {
+		_, e = fileoutput.Open("unix:"+sock, "", fileoutput.Options{RecordsFormat: format})
+		assert.Error(e, format)
+		_, e = fileoutput.Open("-", "", fileoutput.Options{RecordsFormat: format})
+		assert.Error(e, format)
+	}
+	_, e = fileoutput.Open("-", "-", fileoutput.Options{})
+	assert.Error(e)
+}
@@ -0,0 +1,276 @@
+// Package filter selects records with filter expressions.
+//
+// An expression combines predicates with "and", "or", "not", and parentheses:
+//
+//	rx and interest and prefix /ndn/edu and not (cbp or hoplimit < 4)
+//
+// Operators may also be written as "&&", "||", and "!".
+// Values containing spaces, parentheses, or operator characters may be enclosed in single or double quotes.
+//
+// Records contain anonymized addresses, so that IP and MAC address predicates are evaluated against
+// anonymized addresses; see ParseAnonymized.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/usnistgov/ndntdump"
+)
+
+// record is the evaluation context of a record.
+type record struct {
+	*ndntdump.Record
+	fk   ndntdump.FlowKey
+	fkOk bool
+}
+
+// flowKey returns the structured flow key, parsed at most once.
+func (r *record) flowKey() *ndntdump.FlowKey {
+	if !r.fkOk {
+		r.fk, _ = r.Record.FlowKey()
+		r.fkOk = true
+	}
+	return &r.fk
+}
+
+// matcher evaluates a node of the expression.
+type matcher func(r *record) bool
+
+// Filter is a compiled filter expression.
+type Filter struct {
+	src   string
+	match matcher
+}
+
+// String returns the expression.
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.src
+}
+
+// Match determines whether a record matches the filter.
+// A nil Filter matches every record.
+func (f *Filter) Match(rec *ndntdump.Record) bool {
+	if f == nil {
+		return true
+	}
+	return f.match(&record{Record: rec})
+}
+
+// Parse compiles a filter expression.
+// An empty expression returns a nil Filter.
+func Parse(src string) (f *Filter, e error) {
+	return ParseAnonymized(src, nil)
+}
+
+// ParseAnonymized compiles a filter expression for records anonymized by anon.
+// An IP prefix longer than anon preserves, or a MAC address when anon does not keep MAC addresses,
+// is rejected, because it would never match the anonymized address.
+func ParseAnonymized(src string, anon *ndntdump.Anonymizer) (f *Filter, e error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+
+	p := parser{anon: anon}
+	if p.tokens, e = tokenize(src); e != nil {
+		return nil, e
+	}
+	m, e := p.parseOr()
+	if e != nil {
+		return nil, e
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %s", tok)
+	}
+	return &Filter{src: src, match: m}, nil
+}
+
+// operatorChars are characters that form operator tokens.
+const operatorChars = "()!&|=<>~"
+
+// token is a lexical token.
+type token struct {
+	text   string
+	quoted bool
+}
+
+func (tok token) String() string {
+	return fmt.Sprintf("%q", tok.text)
+}
+
+// isOp determines whether the token is an unquoted operator or keyword.
+func (tok token) isOp(ops ...string) bool {
+	if tok.quoted {
+		return false
+	}
+	for _, op := range ops {
+		if strings.EqualFold(tok.text, op) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenize(src string) (tokens []token, e error) {
+	s := []rune(src)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(s) && s[end] != c {
+				end++
+			}
+			if end == len(s) {
+				return nil, errors.New("unterminated quote")
+			}
+			tokens = append(tokens, token{text: string(s[i+1 : end]), quoted: true})
+			i = end + 1
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case strings.ContainsRune(operatorChars, c):
+			end := i + 1
+			for end < len(s) && strings.ContainsRune("&|=", s[end]) {
+				end++
+			}
+			tokens = append(tokens, token{text: string(s[i:end])})
+			i = end
+		default:
+			// a word ends at whitespace or parentheses;
+			// it also ends at operator characters unless it is a name, so that size2>100 is three tokens but /8=A is one
+			end := i + 1
+			for end < len(s) && !unicode.IsSpace(s[end]) && s[end] != '(' && s[end] != ')' &&
+				(s[i] == '/' || !strings.ContainsRune(operatorChars, s[end])) {
+				end++
+			}
+			tokens = append(tokens, token{text: string(s[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	anon   *ndntdump.Anonymizer
+}
+
+func (p *parser) peek() (tok token, ok bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (tok token, e error) {
+	tok, ok := p.peek()
+	if !ok {
+		return tok, errors.New("unexpected end of expression")
+	}
+	p.pos++
+	return tok, nil
+}
+
+func (p *parser) parseOr() (matcher, error) {
+	m, e := p.parseAnd()
+	if e != nil {
+		return nil, e
+	}
+	for {
+		if tok, ok := p.peek(); !ok || !tok.isOp("or", "||") {
+			return m, nil
+		}
+		p.pos++
+		rhs, e := p.parseAnd()
+		if e != nil {
+			return nil, e
+		}
+		lhs := m
+		m = func(r *record) bool { return lhs(r) || rhs(r) }
+	}
+}
+
+func (p *parser) parseAnd() (matcher, error) {
+	m, e := p.parseUnary()
+	if e != nil {
+		return nil, e
+	}
+	for {
+		if tok, ok := p.peek(); !ok || !tok.isOp("and", "&&") {
+			return m, nil
+		}
+		p.pos++
+		rhs, e := p.parseUnary()
+		if e != nil {
+			return nil, e
+		}
+		lhs := m
+		m = func(r *record) bool { return lhs(r) && rhs(r) }
+	}
+}
+
+func (p *parser) parseUnary() (matcher, error) {
+	tok, e := p.next()
+	if e != nil {
+		return nil, e
+	}
+	switch {
+	case tok.isOp("not", "!"):
+		m, e := p.parseUnary()
+		if e != nil {
+			return nil, e
+		}
+		return func(r *record) bool { return !m(r) }, nil
+	case tok.isOp("("):
+		m, e := p.parseOr()
+		if e != nil {
+			return nil, e
+		}
+		if tok, e := p.next(); e != nil || !tok.isOp(")") {
+			return nil, errors.New("missing )")
+		}
+		return m, nil
+	case tok.quoted || strings.ContainsRune(operatorChars, []rune(tok.text)[0]):
+		return nil, fmt.Errorf("unexpected %s", tok)
+	}
+	return p.parsePredicate(strings.ToLower(tok.text))
+}
+
+// value consumes a value token.
+func (p *parser) value(keyword string) (string, error) {
+	tok, e := p.next()
+	if e != nil || (!tok.quoted && strings.ContainsRune(operatorChars, []rune(tok.text)[0])) {
+		return "", fmt.Errorf("%s requires a value", keyword)
+	}
+	return tok.text, nil
+}
+
+// NewOutput wraps a RecordOutput to write only records matching the filter.
+// If f is nil, output is returned unchanged.
+func NewOutput(f *Filter, output ndntdump.RecordOutput) ndntdump.RecordOutput {
+	if f == nil {
+		return output
+	}
+	return filteredOutput{output, f}
+}
+
+type filteredOutput struct {
+	ndntdump.RecordOutput
+	f *Filter
+}
+
+func (o filteredOutput) Write(rec ndntdump.Record) error {
+	if !o.f.Match(&rec) {
+		return nil
+	}
+	return o.RecordOutput.Write(rec)
+}
@@ -0,0 +1,187 @@
+package filter_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/filter"
+)
+
+func makeUDPFlow(local, remote string, localPort, remotePort uint16) []byte {
+	flow := netip.MustParseAddr(local).AsSlice()
+	flow = append(flow, netip.MustParseAddr(remote).AsSlice()...)
+	return append(flow, 17, byte(localPort>>8), byte(localPort), byte(remotePort>>8), byte(remotePort))
+}
+
+var testRecords = []ndntdump.Record{
+	{
+		DirType:     ">I",
+		Name:        ndn.ParseName("/ndn/edu/A"),
+		Flow:        makeUDPFlow("192.168.1.1", "10.0.0.2", 6363, 50000),
+		Size2:       100,
+		CanBePrefix: true,
+		HopLimit:    3,
+	},
+	{
+		DirType:    "<D",
+		Name:       ndn.ParseName("/ndn/edu/A/%00"),
+		Flow:       makeUDPFlow("192.168.1.1", "10.0.0.2", 6363, 50000),
+		Size2:      1500,
+		ContentLen: 1400,
+	},
+	{
+		DirType:    "<N",
+		Name:       ndn.ParseName("/ndn/com/B"),
+		Flow:       makeUDPFlow("192.168.1.1", "172.16.0.3", 6363, 6363),
+		Size2:      80,
+		NackReason: 150,
+	},
+	{
+		DirType: ndntdump.DirTypeDiag,
+		Flow:    makeUDPFlow("192.168.1.1", "172.16.0.3", 6363, 6363),
+	},
+}
+
+func TestFilter(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	match := func(expr string) (indices []int) {
+		f, e := filter.Parse(expr)
+		require.NoError(e, expr)
+		indices = []int{}
+		for i, rec := range testRecords {
+			if f.Match(&rec) {
+				indices = append(indices, i)
+			}
+		}
+		return
+	}
+
+	assert.Equal([]int{0, 1, 2, 3}, match(""))
+	assert.Equal([]int{0}, match("rx"))
+	assert.Equal([]int{1, 2}, match("tx and (data or nack)"))
+	assert.Equal([]int{0, 3}, match("!tx"))
+	assert.Equal([]int{0, 1}, match("prefix /ndn/edu"))
+	assert.Equal([]int{0, 1}, match("prefix /8=ndn/8=edu"))
+	assert.Equal([]int{1}, match(`name ~ "/8=A/8=%00$"`))
+	assert.Equal([]int{0, 1}, match("host 10.0.0.0/8"))
+	assert.Equal([]int{2, 3}, match("remoteip 172.16.0.3 && remoteport == 6363"))
+	assert.Equal([]int{}, match("localip 10.0.0.2"))
+	assert.Equal([]int{0, 1}, match("port 50000"))
+	assert.Equal([]int{2}, match("nackreason = noroute"))
+	assert.Equal([]int{0}, match("hoplimit 3"))
+	assert.Equal([]int{1}, match("namelen 4"))
+	assert.Equal([]int{1}, match("size2>=1000 and size2<=1500"))
+	assert.Equal([]int{0}, match("interest and cbp and not mbf and hoplimit < 4"))
+	assert.Equal([]int{3}, match("diag || contentlen > 2000"))
+	assert.Equal([]int{0, 1, 2}, match("NOT diag AND transport udp"))
+	assert.Equal([]int{2}, match("flow "+ndntdump.FlowID(testRecords[2].Flow)+" and nack"))
+
+	for _, expr := range []string{
+		"rx and",
+		"(rx",
+		"rx)",
+		"unknown",
+		"size2 <",
+		"size2 = >",
+		"size2 > x",
+		"nackreason = everything",
+		"name /A",
+		"name ~ '('",
+		"host 10.0.0.300",
+		"mac 02:00",
+		`prefix "/A`,
+		"and rx",
+	} {
+		_, e := filter.Parse(expr)
+		assert.Error(e, expr)
+	}
+}
+
+func TestFirstFragment(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	rec := ndntdump.Record{
+		DirType:   ">FI",
+		Name:      ndn.ParseName("/ndn/edu/A"),
+		FragCount: 2,
+	}
+	for expr, expected := range map[string]bool{
+		"rx":                  true,
+		"tx":                  false,
+		"fragment":            true,
+		"interest":            true,
+		"data or nack":        false,
+		"rx and interest":     true,
+		"fragment and not rx": false,
+	} {
+		f, e := filter.Parse(expr)
+		require.NoError(e, expr)
+		assert.Equal(expected, f.Match(&rec), expr)
+	}
+}
+
+func TestParseAnonymized(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	keepIPs, e := ndntdump.ParseIPSet([]string{"172.16.0.0/24"})
+	require.NoError(e)
+	anon := ndntdump.NewAnonymizer(keepIPs, false, nil)
+
+	for _, expr := range []string{
+		"host 10.0.0.0/8",
+		"localip 10.0.0.0/24",
+		"remoteip 172.16.0.3",
+		"host 2001:db8::/48",
+	} {
+		_, e := filter.ParseAnonymized(expr, anon)
+		assert.NoError(e, expr)
+	}
+
+	for _, expr := range []string{
+		"host 10.0.0.1",
+		"localip 10.0.0.0/25",
+		"host 2001:db8:1:2::/64",
+		"mac 02:00:00:00:00:01",
+	} {
+		_, e := filter.ParseAnonymized(expr, anon)
+		assert.Error(e, expr)
+	}
+
+	_, e = filter.ParseAnonymized("mac 02:00:00:00:00:01", ndntdump.NewAnonymizer(nil, true, nil))
+	assert.NoError(e)
+}
+
+type countOutput struct {
+	n int
+}
+
+func (o *countOutput) Close() error {
+	return nil
+}
+
+func (o *countOutput) Write(rec ndntdump.Record) error {
+	o.n++
+	return nil
+}
+
+func TestOutput(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	var co countOutput
+	assert.Same(&co, filter.NewOutput(nil, &co))
+
+	f, e := filter.Parse("interest or data")
+	require.NoError(e)
+	assert.Equal("interest or data", f.String())
+	o := filter.NewOutput(f, &co)
+	for _, rec := range testRecords {
+		require.NoError(o.Write(rec))
+	}
+	require.NoError(o.Close())
+	assert.Equal(2, co.n)
+}
@@ -0,0 +1,246 @@
+package filter
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndntdump"
+)
+
+// flagPredicates are predicates without arguments.
+var flagPredicates = map[string]matcher{
+	"rx":          direction(ndntdump.DirectionRX),
+	"tx":          direction(ndntdump.DirectionTX),
+	"fragment":    pktType(ndntdump.PktTypeFragment),
+	"interest":    pktType(ndntdump.PktTypeInterest),
+	"data":        pktType(ndntdump.PktTypeData),
+	"nack":        pktType(ndntdump.PktTypeNack),
+	"diag":        func(r *record) bool { return r.DirType == ndntdump.DirTypeDiag },
+	"cbp":         func(r *record) bool { return r.CanBePrefix },
+	"canbeprefix": func(r *record) bool { return r.CanBePrefix },
+	"mbf":         func(r *record) bool { return r.MustBeFresh },
+	"mustbefresh": func(r *record) bool { return r.MustBeFresh },
+	"appparams":   func(r *record) bool { return r.AppParams },
+	"fwhint":      func(r *record) bool { return len(r.FwHint) > 0 },
+	"finalblock":  func(r *record) bool { return r.FinalBlock },
+	"websocket":   func(r *record) bool { return r.flowKey().WebSocket },
+}
+
+func direction(dir ndntdump.Direction) matcher {
+	return func(r *record) bool { return r.Direction() == dir }
+}
+
+func pktType(t ndntdump.PktType) matcher {
+	return func(r *record) bool { return r.HasPktType(t) }
+}
+
+// intFields are numeric fields usable in comparisons.
+var intFields = map[string]func(r *record) int{
+	"face":         func(r *record) int { return r.Face },
+	"size2":        func(r *record) int { return r.Size2 },
+	"size3":        func(r *record) int { return r.Size3 },
+	"nackreason":   func(r *record) int { return r.NackReason },
+	"congmark":     func(r *record) int { return r.CongMark },
+	"namelen":      func(r *record) int { return len(r.Name) },
+	"lifetime":     func(r *record) int { return r.Lifetime },
+	"hoplimit":     func(r *record) int { return r.HopLimit },
+	"appparamslen": func(r *record) int { return r.AppParamsLen },
+	"sigvaluelen":  func(r *record) int { return r.SigValueLen },
+	"contenttype":  func(r *record) int { return r.ContentType },
+	"freshness":    func(r *record) int { return r.Freshness },
+	"contentlen":   func(r *record) int { return r.ContentLen },
+	"localport":    func(r *record) int { return r.flowKey().LocalPort },
+	"remoteport":   func(r *record) int { return r.flowKey().RemotePort },
+}
+
+// intSymbols are symbolic values of numeric fields.
+var intSymbols = map[string]map[string]int{
+	"nackreason": {
+		"congestion":  an.NackCongestion,
+		"duplicate":   an.NackDuplicate,
+		"noroute":     an.NackNoRoute,
+		"unspecified": an.NackUnspecified,
+	},
+}
+
+var compareOps = map[string]func(a, b int) bool{
+	"=":  func(a, b int) bool { return a == b },
+	"==": func(a, b int) bool { return a == b },
+	"!=": func(a, b int) bool { return a != b },
+	"<":  func(a, b int) bool { return a < b },
+	"<=": func(a, b int) bool { return a <= b },
+	">":  func(a, b int) bool { return a > b },
+	">=": func(a, b int) bool { return a >= b },
+}
+
+func (p *parser) parsePredicate(keyword string) (matcher, error) {
+	if m, ok := flagPredicates[keyword]; ok {
+		return m, nil
+	}
+	if field, ok := intFields[keyword]; ok {
+		return p.parseCompare(keyword, field)
+	}
+
+	switch keyword {
+	case "prefix":
+		v, e := p.value(keyword)
+		if e != nil {
+			return nil, e
+		}
+		prefix := ndn.ParseName(v)
+		return func(r *record) bool { return prefix.IsPrefixOf(r.Name) }, nil
+	case "name":
+		if tok, e := p.next(); e != nil || !tok.isOp("~") {
+			return nil, fmt.Errorf("name requires ~ operator")
+		}
+		v, e := p.value(keyword)
+		if e != nil {
+			return nil, e
+		}
+		re, e := regexp.Compile(v)
+		if e != nil {
+			return nil, e
+		}
+		return func(r *record) bool { return len(r.Name) > 0 && re.MatchString(r.Name.String()) }, nil
+	case "proto":
+		v, e := p.value(keyword)
+		if e != nil {
+			return nil, e
+		}
+		return func(r *record) bool { return r.Proto == v }, nil
+	case "transport":
+		v, e := p.value(keyword)
+		if e != nil {
+			return nil, e
+		}
+		return func(r *record) bool { return r.flowKey().Transport == v }, nil
+	case "flow":
+		v, e := p.value(keyword)
+		if e != nil {
+			return nil, e
+		}
+		return func(r *record) bool {
+			flowID := r.FlowID
+			if flowID == "" {
+				flowID = ndntdump.FlowID(r.Flow)
+			}
+			return flowID == v
+		}, nil
+	case "port":
+		return p.parsePort(keyword)
+	case "host", "localip", "remoteip":
+		return p.parseIP(keyword)
+	case "mac", "localmac", "remotemac":
+		return p.parseMAC(keyword)
+	}
+	return nil, fmt.Errorf("unknown predicate %s", keyword)
+}
+
+func (p *parser) parseCompare(keyword string, field func(r *record) int) (matcher, error) {
+	op, n, e := p.parseCompareRHS(keyword)
+	if e != nil {
+		return nil, e
+	}
+	return func(r *record) bool { return op(field(r), n) }, nil
+}
+
+// parseCompareRHS parses an optional comparison operator and a number.
+// If the operator is omitted, it defaults to equality.
+func (p *parser) parseCompareRHS(keyword string) (op func(a, b int) bool, n int, e error) {
+	op = compareOps["="]
+	if tok, ok := p.peek(); ok && !tok.quoted && compareOps[tok.text] != nil {
+		op = compareOps[tok.text]
+		p.pos++
+	}
+	v, e := p.value(keyword)
+	if e != nil {
+		return nil, 0, e
+	}
+	n, ok := intSymbols[keyword][strings.ToLower(v)]
+	if !ok {
+		if n, e = strconv.Atoi(v); e != nil {
+			return nil, 0, fmt.Errorf("%s: invalid number %s", keyword, v)
+		}
+	}
+	return op, n, nil
+}
+
+func (p *parser) parsePort(keyword string) (matcher, error) {
+	op, n, e := p.parseCompareRHS(keyword)
+	if e != nil {
+		return nil, e
+	}
+	return func(r *record) bool {
+		fk := r.flowKey()
+		return fk.Transport != "" && (op(fk.LocalPort, n) || op(fk.RemotePort, n))
+	}, nil
+}
+
+// matchEither applies a test to local and/or remote values, depending on the keyword prefix.
+func matchEither(keyword string, local, remote func(r *record) string, test func(v string) bool) matcher {
+	switch {
+	case strings.HasPrefix(keyword, "local"):
+		return func(r *record) bool { return test(local(r)) }
+	case strings.HasPrefix(keyword, "remote"):
+		return func(r *record) bool { return test(remote(r)) }
+	}
+	return func(r *record) bool { return test(local(r)) || test(remote(r)) }
+}
+
+func (p *parser) parseIP(keyword string) (matcher, error) {
+	v, e := p.value(keyword)
+	if e != nil {
+		return nil, e
+	}
+	prefix, e := netip.ParsePrefix(v)
+	if e != nil {
+		addr, e := netip.ParseAddr(v)
+		if e != nil {
+			return nil, fmt.Errorf("%s: invalid address %s", keyword, v)
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	prefix = prefix.Masked()
+	if !p.anon.KeepsPrefix(prefix) {
+		return nil, fmt.Errorf("%s: %s is longer than the prefix preserved by address anonymization", keyword, v)
+	}
+
+	return matchEither(keyword,
+		func(r *record) string { return r.flowKey().LocalIP },
+		func(r *record) string { return r.flowKey().RemoteIP },
+		func(v string) bool {
+			addr, e := netip.ParseAddr(v)
+			return e == nil && prefix.Contains(addr.Unmap())
+		},
+	), nil
+}
+
+func (p *parser) parseMAC(keyword string) (matcher, error) {
+	v, e := p.value(keyword)
+	if e != nil {
+		return nil, e
+	}
+	mac, e := net.ParseMAC(v)
+	if e != nil {
+		return nil, fmt.Errorf("%s: invalid address %s", keyword, v)
+	}
+	if !p.anon.KeepsMAC() {
+		return nil, fmt.Errorf("%s: MAC address is anonymized", keyword)
+	}
+	want := mac.String()
+
+	return matchEither(keyword,
+		func(r *record) string { return r.flowKey().LocalMAC },
+		func(r *record) string { return r.flowKey().RemoteMAC },
+		func(v string) bool {
+			mac, e := net.ParseMAC(v)
+			return e == nil && mac.String() == want
+		},
+	), nil
+}
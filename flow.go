@@ -0,0 +1,108 @@
+package ndntdump
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/netip"
+
+	"github.com/gopacket/gopacket/layers"
+)
+
+// Transport values in FlowKey.
+const (
+	TransportEther = "ether"
+	TransportSLL   = "sll"
+	TransportUDP   = "udp"
+	TransportTCP   = "tcp"
+)
+
+// FlowKey is the structured form of a flow key.
+type FlowKey struct {
+	Transport  string `json:"transport,omitempty"`  // ether, sll, udp, tcp
+	WebSocket  bool   `json:"websocket,omitempty"`  // NDN over WebSocket (not recoverable from flow key)
+	LocalMAC   string `json:"localMAC,omitempty"`   // local MAC address
+	RemoteMAC  string `json:"remoteMAC,omitempty"`  // remote MAC address
+	LocalIP    string `json:"localIP,omitempty"`    // local IP address
+	RemoteIP   string `json:"remoteIP,omitempty"`   // remote IP address
+	LocalPort  int    `json:"localPort,omitempty"`  // local UDP/TCP port
+	RemotePort int    `json:"remotePort,omitempty"` // remote UDP/TCP port
+}
+
+// ParseFlowKey parses a flow key saved in Record.Flow.
+// dir is needed to interpret the sender address in SLL link mode.
+//
+// A flow key has one of these layouts:
+//   - empty: no address information.
+//   - 12 octets: local MAC, remote MAC.
+//   - 13 octets: local IPv4, remote IPv4, IP protocol, local port, remote port.
+//   - 37 octets: local IPv6, remote IPv6, IP protocol, local port, remote port.
+//   - up to 8 octets: SLL sender address.
+func ParseFlowKey(flow []byte, dir Direction) (fk FlowKey, e error) {
+	switch len(flow) {
+	case 0:
+	case 12:
+		fk.Transport = TransportEther
+		fk.LocalMAC, fk.RemoteMAC = net.HardwareAddr(flow[:6]).String(), net.HardwareAddr(flow[6:]).String()
+	case 13, 37:
+		ipLen := (len(flow) - 5) / 2
+		local, _ := netip.AddrFromSlice(flow[:ipLen])
+		remote, _ := netip.AddrFromSlice(flow[ipLen : 2*ipLen])
+		fk.LocalIP, fk.RemoteIP = local.String(), remote.String()
+
+		ports := flow[2*ipLen:]
+		switch layers.IPProtocol(ports[0]) {
+		case layers.IPProtocolUDP:
+			fk.Transport = TransportUDP
+		case layers.IPProtocolTCP:
+			fk.Transport = TransportTCP
+		default:
+			return FlowKey{}, errors.New("unknown IP protocol in flow key")
+		}
+		fk.LocalPort = int(ports[1])<<8 | int(ports[2])
+		fk.RemotePort = int(ports[3])<<8 | int(ports[4])
+	default:
+		if len(flow) > 8 {
+			return FlowKey{}, errors.New("bad flow key length")
+		}
+		fk.Transport = TransportSLL
+		if dir == DirectionTX {
+			fk.LocalMAC = net.HardwareAddr(flow).String()
+		} else {
+			fk.RemoteMAC = net.HardwareAddr(flow).String()
+		}
+	}
+	return fk, nil
+}
+
+// FlowID returns a compact identifier of a flow key.
+// It is the hexadecimal representation of the first 8 octets of SHA-256 digest of the flow key.
+// Since the flow key contains anonymized addresses, it is stable only within one run.
+func FlowID(flow []byte) string {
+	if len(flow) == 0 {
+		return ""
+	}
+	digest := sha256.Sum256(flow)
+	return hex.EncodeToString(digest[:8])
+}
+
+// FlowKey returns the structured flow key of this Record.
+// If FlowInfo is absent, it is parsed from Flow.
+func (rec Record) FlowKey() (FlowKey, error) {
+	if rec.FlowInfo != nil {
+		return *rec.FlowInfo, nil
+	}
+	var dir Direction
+	if len(rec.DirType) > 0 {
+		dir = Direction(rec.DirType[:1])
+	}
+	return ParseFlowKey(rec.Flow, dir)
+}
+
+func (rec *Record) saveFlowInfo(dir Direction) {
+	if fk, e := ParseFlowKey(rec.Flow, dir); e == nil && fk.Transport != "" {
+		rec.FlowInfo = &fk
+	}
+	rec.FlowID = FlowID(rec.Flow)
+}
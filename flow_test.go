@@ -0,0 +1,134 @@
+package ndntdump_test
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/pcapinput"
+	"github.com/usnistgov/ndntdump/websocket"
+)
+
+func TestParseFlowKey(t *testing.T) {
+	assert := assert.New(t)
+
+	fk, e := ndntdump.ParseFlowKey(nil, ndntdump.DirectionRX)
+	assert.NoError(e)
+	assert.Zero(fk)
+
+	ip6 := netip.MustParseAddr("2001:db8::1").AsSlice()
+	flow := append(append(append([]byte{}, ip6...), ip6...), 6, 0x25, 0xD0, 0xC0, 0x00)
+	fk, e = ndntdump.ParseFlowKey(flow, ndntdump.DirectionRX)
+	assert.NoError(e)
+	assert.Equal(ndntdump.FlowKey{
+		Transport:  ndntdump.TransportTCP,
+		LocalIP:    "2001:db8::1",
+		RemoteIP:   "2001:db8::1",
+		LocalPort:  9680,
+		RemotePort: 49152,
+	}, fk)
+
+	fk, e = ndntdump.ParseFlowKey([]byte{2, 0, 0, 0, 0, 3}, ndntdump.DirectionRX)
+	assert.NoError(e)
+	assert.Equal(ndntdump.FlowKey{Transport: ndntdump.TransportSLL, RemoteMAC: "02:00:00:00:00:03"}, fk)
+	fk, e = ndntdump.ParseFlowKey([]byte{2, 0, 0, 0, 0, 3}, ndntdump.DirectionTX)
+	assert.NoError(e)
+	assert.Equal(ndntdump.FlowKey{Transport: ndntdump.TransportSLL, LocalMAC: "02:00:00:00:00:03"}, fk)
+
+	_, e = ndntdump.ParseFlowKey(make([]byte, 20), ndntdump.DirectionRX)
+	assert.Error(e)
+}
+
+func TestReaderFlowInfo(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	interest, _ := tlv.EncodeFrom(ndn.MakeInterest("/A"))
+	localMAC, remoteMAC := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01}, net.HardwareAddr{0x02, 0, 0, 0, 0, 0x02}
+	localIP, remoteIP := net.IP{192, 0, 2, 1}, net.IP{192, 0, 2, 2}
+	makeFrame := func(l4 gopacket.SerializableLayer, payload []byte) []byte {
+		eth := &layers.Ethernet{SrcMAC: remoteMAC, DstMAC: localMAC, EthernetType: layers.EthernetTypeIPv4}
+		ip4 := &layers.IPv4{Version: 4, TTL: 64, SrcIP: remoteIP, DstIP: localIP}
+		switch l4 := l4.(type) {
+		case *layers.UDP:
+			ip4.Protocol = layers.IPProtocolUDP
+			l4.SetNetworkLayerForChecksum(ip4)
+		case *layers.TCP:
+			ip4.Protocol = layers.IPProtocolTCP
+			l4.SetNetworkLayerForChecksum(ip4)
+		}
+		buf := gopacket.NewSerializeBuffer()
+		require.NoError(gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
+			eth, ip4, l4, gopacket.Payload(payload)))
+		return buf.Bytes()
+	}
+
+	var b bytes.Buffer
+	w, e := pcapgo.NewNgWriter(&b, layers.LinkTypeEthernet)
+	require.NoError(e)
+	for _, frame := range [][]byte{
+		makeFrame(&layers.UDP{SrcPort: 6363, DstPort: 56363}, interest),
+		makeFrame(&layers.TCP{SrcPort: 49152, DstPort: 9696, ACK: true, PSH: true, Window: 1024},
+			append([]byte{websocket.FlagFin | websocket.OpBinary, byte(len(interest))}, interest...)),
+	} {
+		require.NoError(w.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     time.Unix(1700000000, 0),
+			CaptureLength: len(frame),
+			Length:        len(frame),
+		}, frame))
+	}
+	require.NoError(w.Flush())
+	filename := filepath.Join(t.TempDir(), "flow.pcapng")
+	require.NoError(os.WriteFile(filename, b.Bytes(), 0o644))
+
+	input, e := pcapinput.Open("", filename, []string{localMAC.String()})
+	require.NoError(e)
+	defer input.Close()
+
+	keepIPs, _ := ndntdump.ParseIPSet([]string{"192.0.2.0/24"})
+	records := readAllRecords(t, input, ndntdump.ReaderOptions{
+		FlowInfo:      true,
+		WebSocketPort: 9696,
+		Anonymizer:    ndntdump.NewAnonymizer(keepIPs, true, nil),
+	})
+	require.Len(records, 3)
+
+	rec := records[0]
+	assert.Equal(">I", rec.DirType)
+	assert.Equal(ndntdump.FlowID(rec.Flow), rec.FlowID)
+	assert.Len(rec.FlowID, 16)
+	require.NotNil(rec.FlowInfo)
+	assert.Equal(ndntdump.FlowKey{
+		Transport:  ndntdump.TransportUDP,
+		LocalIP:    "192.0.2.1",
+		RemoteIP:   "192.0.2.2",
+		LocalPort:  56363,
+		RemotePort: 6363,
+	}, *rec.FlowInfo)
+
+	assert.Empty(records[1].DirType) // TCP segment
+
+	rec = records[2]
+	assert.Equal(">I", rec.DirType)
+	assert.NotEqual(records[0].FlowID, rec.FlowID)
+	require.NotNil(rec.FlowInfo)
+	assert.Equal(ndntdump.FlowKey{
+		Transport:  ndntdump.TransportTCP,
+		WebSocket:  true,
+		LocalIP:    "192.0.2.1",
+		RemoteIP:   "192.0.2.2",
+		LocalPort:  9696,
+		RemotePort: 49152,
+	}, *rec.FlowInfo)
+}
@@ -0,0 +1,184 @@
+// Command schemagen generates JSON Schema of the records file from ndntdump.Header and ndntdump.Record.
+//
+// Usage: go run ./internal/schemagen OUTPUT-FILE
+// It must be invoked in the ndntdump package directory, where field descriptions are read from source code comments.
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/usnistgov/ndntdump"
+)
+
+// parseFieldDocs extracts struct field comments as "Type.Field" => comment.
+func parseFieldDocs(dir string) (docs map[string]string, e error) {
+	filenames, e := filepath.Glob(filepath.Join(dir, "*.go"))
+	if e != nil {
+		return nil, e
+	}
+
+	docs = map[string]string{}
+	fset := token.NewFileSet()
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		f, e := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if e != nil {
+			return nil, e
+		}
+
+		ast.Inspect(f, func(node ast.Node) bool {
+			ts, ok := node.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+			for _, field := range st.Fields.List {
+				doc := field.Comment
+				if doc == nil {
+					doc = field.Doc
+				}
+				for _, name := range field.Names {
+					docs[ts.Name.Name+"."+name.Name] = strings.Join(strings.Fields(doc.Text()), " ")
+				}
+			}
+			return false
+		})
+	}
+	return docs, nil
+}
+
+var (
+	typeTime          = reflect.TypeFor[time.Time]()
+	typeTextMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+type generator struct {
+	docs map[string]string
+	defs map[string]any
+}
+
+func (g *generator) schemaOf(t reflect.Type) map[string]any {
+	switch {
+	case t == typeTime:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Implements(typeTextMarshaler):
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object"}
+	case reflect.Pointer:
+		return g.schemaOf(t.Elem())
+	case reflect.Struct:
+		return g.ref(t)
+	}
+	return map[string]any{}
+}
+
+func (g *generator) ref(t reflect.Type) map[string]any {
+	if _, ok := g.defs[t.Name()]; !ok {
+		g.defs[t.Name()] = nil // prevent infinite recursion
+		g.defs[t.Name()] = g.object(t)
+	}
+	return map[string]any{"$ref": "#/$defs/" + t.Name()}
+}
+
+func (g *generator) object(t reflect.Type) map[string]any {
+	properties, required := map[string]any{}, []string{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+
+		prop := g.schemaOf(field.Type)
+		if doc := g.docs[t.Name()+"."+field.Name]; doc != "" {
+			prop["description"] = doc
+		}
+		properties[key] = prop
+		if opts != "omitempty" {
+			required = append(required, key)
+		}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func generate(dir string) ([]byte, error) {
+	docs, e := parseFieldDocs(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	g := generator{
+		docs: docs,
+		defs: map[string]any{},
+	}
+	schema := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "ndntdump records file",
+		"description": fmt.Sprintf("Schema version %d. Each line is either a Header or a Record.", ndntdump.SchemaVersion),
+		"oneOf": []any{
+			g.ref(reflect.TypeFor[ndntdump.Header]()),
+			g.ref(reflect.TypeFor[ndntdump.Record]()),
+		},
+		"$defs": g.defs,
+	}
+
+	j, e := json.MarshalIndent(schema, "", "  ")
+	return append(j, '\n'), e
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: schemagen OUTPUT-FILE")
+		os.Exit(2)
+	}
+
+	j, e := generate(".")
+	if e == nil {
+		e = os.WriteFile(os.Args[1], j, 0o644)
+	}
+	if e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpToDate(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	j, e := generate("../..")
+	require.NoError(e)
+	committed, e := os.ReadFile("../../record.schema.json")
+	require.NoError(e)
+	assert.JSONEq(string(committed), string(j), "record.schema.json is outdated, run 'go generate' in repository root")
+}
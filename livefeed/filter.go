@@ -0,0 +1,103 @@
+package livefeed
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+)
+
+// Filter selects records delivered to a subscriber.
+// A record must match every non-empty field, and matches a field if it matches any value in that field.
+type Filter struct {
+	Dir     []ndntdump.Direction
+	PktType []ndntdump.PktType
+	Prefix  []ndn.Name
+	Flow    []string // flow ID
+}
+
+// Match determines whether a record matches the filter.
+func (f Filter) Match(rec *ndntdump.Record) bool {
+	if len(f.Dir) > 0 && !slices.Contains(f.Dir, rec.Direction()) {
+		return false
+	}
+	if len(f.PktType) > 0 && !slices.ContainsFunc(f.PktType, rec.HasPktType) {
+		return false
+	}
+	if len(f.Prefix) > 0 && !slices.ContainsFunc(f.Prefix, func(prefix ndn.Name) bool { return prefix.IsPrefixOf(rec.Name) }) {
+		return false
+	}
+	if len(f.Flow) > 0 {
+		flowID := rec.FlowID
+		if flowID == "" {
+			flowID = ndntdump.FlowID(rec.Flow)
+		}
+		if !slices.Contains(f.Flow, flowID) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	dirValues = map[string]ndntdump.Direction{
+		"rx": ndntdump.DirectionRX,
+		">":  ndntdump.DirectionRX,
+		"tx": ndntdump.DirectionTX,
+		"<":  ndntdump.DirectionTX,
+	}
+	pktTypeValues = map[string]ndntdump.PktType{
+		"f":        ndntdump.PktTypeFragment,
+		"fragment": ndntdump.PktTypeFragment,
+		"i":        ndntdump.PktTypeInterest,
+		"interest": ndntdump.PktTypeInterest,
+		"d":        ndntdump.PktTypeData,
+		"data":     ndntdump.PktTypeData,
+		"n":        ndntdump.PktTypeNack,
+		"nack":     ndntdump.PktTypeNack,
+	}
+)
+
+// queryValues returns comma-separated values of a repeatable query parameter.
+func queryValues(query url.Values, key string) (values []string) {
+	for _, v := range query[key] {
+		for _, token := range strings.Split(v, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				values = append(values, token)
+			}
+		}
+	}
+	return values
+}
+
+// ParseFilter parses a filter from URL query parameters.
+//   - dir: rx or tx.
+//   - type: I, D, N, or F.
+//   - prefix: name prefix in URI format.
+//   - flow: flow ID, as in flowId property of records.
+//
+// Each parameter may be repeated or contain comma-separated values.
+func ParseFilter(query url.Values) (f Filter, e error) {
+	for _, v := range queryValues(query, "dir") {
+		dir, ok := dirValues[strings.ToLower(v)]
+		if !ok {
+			return f, fmt.Errorf("invalid dir %s", v)
+		}
+		f.Dir = append(f.Dir, dir)
+	}
+	for _, v := range queryValues(query, "type") {
+		pktType, ok := pktTypeValues[strings.ToLower(v)]
+		if !ok {
+			return f, fmt.Errorf("invalid type %s", v)
+		}
+		f.PktType = append(f.PktType, pktType)
+	}
+	for _, v := range query["prefix"] {
+		f.Prefix = append(f.Prefix, ndn.ParseName(v))
+	}
+	f.Flow = queryValues(query, "flow")
+	return f, nil
+}
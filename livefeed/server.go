@@ -0,0 +1,304 @@
+// Package livefeed publishes live records over WebSocket and Server-Sent Events.
+package livefeed
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/websocket"
+)
+
+// Options contains Server options.
+type Options struct {
+	// Header is sent as the first message to each subscriber, with schema version and start time filled in.
+	Header ndntdump.Header
+
+	// Buffer is the maximum number of records queued for each subscriber.
+	// When a subscriber's queue is full, further records are dropped for that subscriber.
+	// Default is 1024.
+	Buffer int
+
+	// AllowOrigins lists web origins, such as "https://dashboard.example.net", permitted to subscribe.
+	// A request with an Origin header not in this list is rejected, so that arbitrary web pages
+	// visited by the operator cannot read the records.
+	// Requests without an Origin header, which are not sent by browsers, are always permitted.
+	AllowOrigins []string
+}
+
+func (opts *Options) applyDefaults() {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 1024
+	}
+}
+
+// sendTimeout is the maximum duration for sending a message to a subscriber.
+const sendTimeout = 10 * time.Second
+
+// closeTimeout is the maximum duration for subscribers to disconnect upon Close.
+const closeTimeout = time.Second
+
+type subscriber struct {
+	filter Filter
+	queue  chan []byte
+}
+
+// Server publishes records to subscribers over WebSocket or Server-Sent Events (SSE).
+//
+// Each request to path "/" subscribes to the records stream, with a Filter specified in URL query parameters.
+// A request with WebSocket upgrade receives each record as a text message.
+// Other requests receive each record as an SSE event.
+// In either case, the first message is the header.
+// Browser requests are accepted only from origins in Options.AllowOrigins.
+//
+// Records are encoded as JSON, in the same format as NDJSON records file.
+// A slow subscriber loses records when its queue is full, but does not stall the capture.
+type Server struct {
+	opts    Options
+	hdr     []byte
+	srv     *http.Server
+	ln      net.Listener
+	mu      sync.Mutex
+	subs    map[*subscriber]bool
+	closing bool
+	conns   sync.WaitGroup
+	dropped atomic.Uint64
+}
+
+var _ interface {
+	ndntdump.RecordOutput
+	http.Handler
+} = &Server{}
+
+// Addr returns the listener address.
+func (s *Server) Addr() net.Addr {
+	return s.ln.Addr()
+}
+
+// Subscribers returns the number of connected subscribers.
+func (s *Server) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs)
+}
+
+// Dropped returns the number of records dropped due to full subscriber queues.
+func (s *Server) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closing = true
+	for sub := range s.subs {
+		delete(s.subs, sub)
+		close(sub.queue)
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	e := s.srv.Shutdown(ctx)
+	if errors.Is(e, context.DeadlineExceeded) {
+		e = s.srv.Close()
+	}
+	s.conns.Wait()
+	return e
+}
+
+func (s *Server) Write(rec ndntdump.Record) error {
+	if len(rec.DirType) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var msg []byte
+	for sub := range s.subs {
+		if !sub.filter.Match(&rec) {
+			continue
+		}
+		if len(sub.queue) == cap(sub.queue) {
+			s.dropped.Add(1)
+			continue
+		}
+		if msg == nil {
+			var e error
+			if msg, e = json.Marshal(rec); e != nil {
+				return e
+			}
+		}
+		sub.queue <- msg
+	}
+	return nil
+}
+
+func (s *Server) subscribe(filter Filter) *subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return nil
+	}
+	sub := &subscriber{
+		filter: filter,
+		queue:  make(chan []byte, s.opts.Buffer+1),
+	}
+	sub.queue <- s.hdr
+	s.subs[sub] = true
+	return sub
+}
+
+func (s *Server) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs[sub] {
+		delete(s.subs, sub)
+		close(sub.queue)
+	}
+}
+
+// ServeHTTP handles a subscription request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	origin := r.Header.Get("Origin")
+	if origin != "" && !slices.Contains(s.opts.AllowOrigins, origin) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	filter, e := ParseFilter(r.URL.Query())
+	if e != nil {
+		http.Error(w, e.Error(), http.StatusBadRequest)
+		return
+	}
+
+	isWebSocket := strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if isWebSocket && key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return
+	}
+
+	sub := s.subscribe(filter)
+	if sub == nil {
+		http.Error(w, "server closing", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.unsubscribe(sub)
+
+	if isWebSocket {
+		s.serveWebSocket(w, key, sub)
+	} else {
+		s.serveSSE(w, r, origin, sub)
+	}
+}
+
+func (s *Server) serveSSE(w http.ResponseWriter, r *http.Request, origin string, sub *subscriber) {
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Vary", "Origin")
+	if origin != "" {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.queue:
+			if !ok {
+				return
+			}
+			rc.SetWriteDeadline(time.Now().Add(sendTimeout))
+			if _, e := fmt.Fprintf(w, "data: %s\n\n", msg); e != nil {
+				return
+			}
+			if rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) serveWebSocket(w http.ResponseWriter, key string, sub *subscriber) {
+	conn, brw, e := http.NewResponseController(w).Hijack()
+	if e != nil {
+		http.Error(w, e.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.conns.Add(1)
+	defer s.conns.Done()
+	defer conn.Close()
+
+	fmt.Fprintf(brw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		websocket.AcceptKey(key))
+	if brw.Flush() != nil {
+		return
+	}
+
+	// client messages are ignored; reading fails when the client disconnects
+	disconnect := make(chan struct{})
+	go func(r *bufio.Reader) {
+		defer close(disconnect)
+		io.Copy(io.Discard, r)
+	}(brw.Reader)
+
+	for {
+		select {
+		case <-disconnect:
+			return
+		case msg, ok := <-sub.queue:
+			if !ok {
+				conn.SetWriteDeadline(time.Now().Add(closeTimeout))
+				conn.Write(websocket.Frame{FlagOp: websocket.FlagFin | websocket.OpClose}.Encode())
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(sendTimeout))
+			if _, e := conn.Write(websocket.Frame{FlagOp: websocket.FlagFin | websocket.OpText, Payload: msg}.Encode()); e != nil {
+				return
+			}
+		}
+	}
+}
+
+// NewServer creates Server listening on a TCP address.
+func NewServer(addr string, opts Options) (s *Server, e error) {
+	opts.applyDefaults()
+
+	hdr := opts.Header
+	hdr.Type, hdr.Schema, hdr.Start = ndntdump.DirTypeHeader, ndntdump.SchemaVersion, time.Now()
+	s = &Server{
+		opts: opts,
+		subs: map[*subscriber]bool{},
+	}
+	if s.hdr, e = json.Marshal(hdr); e != nil {
+		return nil, e
+	}
+
+	if s.ln, e = net.Listen("tcp", addr); e != nil {
+		return nil, e
+	}
+	s.srv = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go s.srv.Serve(s.ln)
+	return s, nil
+}
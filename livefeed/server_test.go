@@ -0,0 +1,202 @@
+package livefeed_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/livefeed"
+	"github.com/usnistgov/ndntdump/websocket"
+)
+
+var testRecords = []ndntdump.Record{
+	{DirType: ">I", Name: ndn.ParseName("/A/1"), Flow: []byte{0xA0}},
+	{DirType: "<D", Name: ndn.ParseName("/A/1"), Flow: []byte{0xA0}},
+	{DirType: ">I", Name: ndn.ParseName("/B/1"), Flow: []byte{0xA1}},
+	{DirType: ndntdump.DirTypeDiag},
+}
+
+func TestFilter(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	match := func(query string) (dirTypes []string) {
+		q, e := url.ParseQuery(query)
+		require.NoError(e)
+		f, e := livefeed.ParseFilter(q)
+		require.NoError(e)
+		for _, rec := range testRecords {
+			if f.Match(&rec) {
+				dirTypes = append(dirTypes, rec.DirType+rec.Name.String())
+			}
+		}
+		return
+	}
+
+	assert.Len(match(""), 4)
+	assert.Equal([]string{">I/8=A/8=1", ">I/8=B/8=1"}, match("dir=rx"))
+	assert.Equal([]string{"<D/8=A/8=1"}, match("type=D,N"))
+	assert.Equal([]string{">I/8=A/8=1", "<D/8=A/8=1"}, match("prefix=/A"))
+	assert.Equal([]string{">I/8=B/8=1"}, match("flow="+ndntdump.FlowID([]byte{0xA1})))
+	assert.Equal([]string{">I/8=A/8=1"}, match("dir=%3E&type=interest&prefix=/A&prefix=/C"))
+
+	frag := ndntdump.Record{DirType: ">FI", Name: ndn.ParseName("/A/2")}
+	for query, expected := range map[string]bool{
+		"type=F":        true,
+		"type=I":        true,
+		"type=D,N":      false,
+		"dir=rx&type=I": true,
+		"dir=tx":        false,
+	} {
+		values, _ := url.ParseQuery(query)
+		f, e := livefeed.ParseFilter(values)
+		require.NoError(e, query)
+		assert.Equal(expected, f.Match(&frag), query)
+	}
+
+	_, e := livefeed.ParseFilter(url.Values{"dir": {"up"}})
+	assert.Error(e)
+	_, e = livefeed.ParseFilter(url.Values{"type": {"X"}})
+	assert.Error(e)
+}
+
+func newTestServer(t testing.TB) *livefeed.Server {
+	s, e := livefeed.NewServer("127.0.0.1:0", livefeed.Options{
+		Header:       ndntdump.Header{Tool: "test"},
+		AllowOrigins: []string{"https://dashboard.example.net"},
+	})
+	require.NoError(t, e)
+	return s
+}
+
+func waitSubscribers(t testing.TB, s *livefeed.Server, n int) {
+	require.Eventually(t, func() bool { return s.Subscribers() == n }, time.Second, time.Millisecond)
+}
+
+func writeTestRecords(t testing.TB, s *livefeed.Server) {
+	for _, rec := range testRecords {
+		require.NoError(t, s.Write(rec))
+	}
+	require.NoError(t, s.Close())
+}
+
+func TestSSE(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	s := newTestServer(t)
+	res, e := http.Get("http://" + s.Addr().String() + "/?dir=up")
+	require.NoError(e)
+	res.Body.Close()
+	assert.Equal(http.StatusBadRequest, res.StatusCode)
+
+	res, e = http.Get("http://" + s.Addr().String() + "/other")
+	require.NoError(e)
+	res.Body.Close()
+	assert.Equal(http.StatusNotFound, res.StatusCode)
+
+	req, e := http.NewRequest(http.MethodGet, "http://"+s.Addr().String()+"/", nil)
+	require.NoError(e)
+	req.Header.Set("Origin", "https://evil.example.com")
+	res, e = http.DefaultClient.Do(req)
+	require.NoError(e)
+	res.Body.Close()
+	assert.Equal(http.StatusForbidden, res.StatusCode)
+	assert.Empty(res.Header.Get("Access-Control-Allow-Origin"))
+
+	req, e = http.NewRequest(http.MethodGet, "http://"+s.Addr().String()+"/?type=I&prefix=/B", nil)
+	require.NoError(e)
+	req.Header.Set("Origin", "https://dashboard.example.net")
+	res, e = http.DefaultClient.Do(req)
+	require.NoError(e)
+	defer res.Body.Close()
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("text/event-stream", res.Header.Get("Content-Type"))
+	assert.Equal("https://dashboard.example.net", res.Header.Get("Access-Control-Allow-Origin"))
+
+	waitSubscribers(t, s, 1)
+	writeTestRecords(t, s)
+
+	var events []map[string]any
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event map[string]any
+		require.NoError(json.Unmarshal([]byte(data), &event))
+		events = append(events, event)
+	}
+	require.Len(events, 2)
+	assert.Equal(ndntdump.DirTypeHeader, events[0]["t"])
+	assert.Equal("test", events[0]["tool"])
+	assert.Equal(">I", events[1]["t"])
+	assert.Equal("/8=B/8=1", events[1]["name"])
+}
+
+func TestWebSocket(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	s := newTestServer(t)
+	conn, e := net.Dial("tcp", s.Addr().String())
+	require.NoError(e)
+	defer conn.Close()
+	_, e = io.WriteString(conn, "GET /?dir=tx HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	require.NoError(e)
+
+	r := bufio.NewReader(conn)
+	res, e := http.ReadResponse(r, nil)
+	require.NoError(e)
+	assert.Equal(http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-WebSocket-Accept"))
+
+	waitSubscribers(t, s, 1)
+	writeTestRecords(t, s)
+
+	input, e := io.ReadAll(r)
+	require.NoError(e)
+	var frames []websocket.Frame
+	for len(input) > 0 {
+		var f websocket.Frame
+		input, e = f.Decode(input)
+		require.NoError(e)
+		frames = append(frames, f)
+	}
+	require.Len(frames, 3)
+	assert.EqualValues(websocket.FlagFin|websocket.OpText, frames[0].FlagOp)
+	assert.Contains(string(frames[0].Payload), `"t":"#"`)
+	assert.EqualValues(websocket.FlagFin|websocket.OpText, frames[1].FlagOp)
+	var rec ndntdump.Record
+	require.NoError(json.Unmarshal(frames[1].Payload, &rec))
+	assert.Equal("<D", rec.DirType)
+	assert.EqualValues(websocket.FlagFin|websocket.OpClose, frames[2].FlagOp)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	s := newTestServer(t)
+	defer s.Close()
+	conn, e := net.Dial("tcp", s.Addr().String())
+	require.NoError(e)
+	defer conn.Close()
+	_, e = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nOrigin: https://evil.example.com\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	require.NoError(e)
+
+	res, e := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(e)
+	res.Body.Close()
+	assert.Equal(http.StatusForbidden, res.StatusCode)
+	assert.Equal(0, s.Subscribers())
+}
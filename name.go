@@ -0,0 +1,87 @@
+package ndntdump
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
+)
+
+// NameComp is a structured name component.
+type NameComp struct {
+	// Type is the component type: generic, keyword, implicitDigest, paramsDigest,
+	// segment, byteOffset, version, timestamp, seqNum, or decimal TLV-TYPE of other types.
+	Type string `json:"t"`
+
+	// Value is the URI-escaped TLV-VALUE, or hexadecimal digest.
+	// It is omitted if Num is present.
+	Value string `json:"v,omitempty"`
+
+	// Num is the number encoded in segment, byteOffset, version, timestamp, or seqNum component.
+	Num *uint64 `json:"n,omitempty"`
+}
+
+var nameCompNumberTypes = map[uint32]string{
+	an.TtSegmentNameComponent:     "segment",
+	an.TtByteOffsetNameComponent:  "byteOffset",
+	an.TtVersionNameComponent:     "version",
+	an.TtTimestampNameComponent:   "timestamp",
+	an.TtSequenceNumNameComponent: "seqNum",
+}
+
+// MakeNameComp converts a name component to structured form.
+func MakeNameComp(comp ndn.NameComponent) (nc NameComp) {
+	switch comp.Type {
+	case an.TtImplicitSha256DigestComponent, an.TtParametersSha256DigestComponent:
+		nc.Type = "implicitDigest"
+		if comp.Type == an.TtParametersSha256DigestComponent {
+			nc.Type = "paramsDigest"
+		}
+		nc.Value = hex.EncodeToString(comp.Value)
+		return
+	}
+
+	if typ, ok := nameCompNumberTypes[comp.Type]; ok {
+		var n tlv.NNI
+		if n.UnmarshalBinary(comp.Value) == nil {
+			nc.Type = typ
+			nc.Num = (*uint64)(&n)
+			return
+		}
+	}
+
+	switch comp.Type {
+	case an.TtGenericNameComponent:
+		nc.Type = "generic"
+	case an.TtKeywordNameComponent:
+		nc.Type = "keyword"
+	default:
+		nc.Type = strconv.FormatUint(uint64(comp.Type), 10)
+	}
+	_, nc.Value, _ = strings.Cut(comp.String(), "=")
+	return
+}
+
+// saveNameInfo saves derived name properties on this Record.
+// If comps is true, structured name components are saved.
+// If prefixLen is positive, the name prefix truncated to that many components is saved.
+func (rec *Record) saveNameInfo(comps bool, prefixLen int) {
+	if len(rec.Name) == 0 {
+		return
+	}
+	rec.NameLen = len(rec.Name)
+	rec.NameSize = rec.Name.Length()
+
+	if comps {
+		rec.NameComps = make([]NameComp, len(rec.Name))
+		for i, comp := range rec.Name {
+			rec.NameComps[i] = MakeNameComp(comp)
+		}
+	}
+	if prefixLen > 0 {
+		rec.Prefix = rec.Name.GetPrefix(min(prefixLen, len(rec.Name)))
+	}
+}
@@ -0,0 +1,72 @@
+package ndntdump_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/gopacket/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/pcapinput"
+)
+
+func TestMakeNameComp(t *testing.T) {
+	assert := assert.New(t)
+
+	digest := bytes.Repeat([]byte{0xA0}, 32)
+	for comp, expect := range map[string]string{
+		"A":               `{"t":"generic","v":"A"}`,
+		"32=%20%2F":       `{"t":"keyword","v":"%20%2F"}`,
+		"50=%00":          `{"t":"segment","n":0}`,
+		"52=%01%00":       `{"t":"byteOffset","n":256}`,
+		"54=%02":          `{"t":"version","n":2}`,
+		"56=%00%00%00%03": `{"t":"timestamp","n":3}`,
+		"58=%04":          `{"t":"seqNum","n":4}`,
+		"50=%00%01%02":    `{"t":"50","v":"%00%01%02"}`,
+		"9=A":             `{"t":"9","v":"A"}`,
+	} {
+		j, _ := json.Marshal(ndntdump.MakeNameComp(ndn.ParseNameComponent(comp)))
+		assert.JSONEq(expect, string(j), comp)
+	}
+
+	nc := ndntdump.MakeNameComp(ndn.MakeNameComponent(an.TtImplicitSha256DigestComponent, digest))
+	assert.Equal("implicitDigest", nc.Type)
+	assert.Equal("a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0", nc.Value)
+	nc = ndntdump.MakeNameComp(ndn.MakeNameComponent(an.TtParametersSha256DigestComponent, digest))
+	assert.Equal("paramsDigest", nc.Type)
+}
+
+func TestReaderNameInfo(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	read := func(name string) ndntdump.Record {
+		input, e := pcapinput.Open("", writeNdndpdkTrace(t, makeSLL(layers.LinuxSLLPacketTypeHost, ndn.MakeInterest(name))), nil)
+		require.NoError(e)
+		defer input.Close()
+
+		records := readAllRecords(t, input, ndntdump.ReaderOptions{
+			NameComponents: true,
+			NamePrefixLen:  2,
+		})
+		require.Len(records, 1)
+		return records[0]
+	}
+
+	rec := read("/A/B/54=%02/50=%00")
+	assert.Equal(4, rec.NameLen)
+	assert.Equal(12, rec.NameSize)
+	require.Len(rec.NameComps, 4)
+	assert.Equal("generic", rec.NameComps[1].Type)
+	assert.Equal("B", rec.NameComps[1].Value)
+	assert.Equal("version", rec.NameComps[2].Type)
+	assert.Equal(uint64(2), *rec.NameComps[2].Num)
+	assert.Equal("/8=A/8=B", rec.Prefix.String())
+
+	rec = read("/A")
+	assert.Equal(1, rec.NameLen)
+	assert.Equal("/8=A", rec.Prefix.String())
+}
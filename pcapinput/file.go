@@ -1,61 +1,141 @@
 package pcapinput
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
 	"github.com/gopacket/gopacket/pcapgo"
 	"github.com/klauspost/compress/zstd"
 	"github.com/usnistgov/ndn-dpdk/core/macaddr"
+	"github.com/zyedidia/generic/mapset"
 )
 
 type fileHandle struct {
-	local      net.HardwareAddr
-	file       *os.File
-	decompress io.ReadCloser
-	reader     *pcapgo.Reader
-	ngr        *pcapgo.NgReader
+	locals      mapset.Set[[6]byte]
+	namedLocals map[string]net.HardwareAddr
+	idb         idbScanner
+	file        *os.File
+	decompress  io.ReadCloser
+	reader      *pcapgo.Reader
+	ngr         *pcapgo.NgReader
+
+	follow   bool
+	filename string
+	tail     *followFile
+	failed   error // terminal error after a rotated file cannot be opened
+	mu       sync.RWMutex
+	closing  atomic.Bool
+}
+
+const ndndpdkApplication = "NDN-DPDK"
+
+type fileFormat int
+
+const (
+	formatUnknown fileFormat = iota
+	formatGzip
+	formatZstd
+	formatPcap
+	formatPcapng
+)
+
+// detectFormat determines file format from magic bytes.
+func detectFormat(magic []byte) fileFormat {
+	if len(magic) < 4 {
+		return formatUnknown
+	}
+	switch {
+	case magic[0] == 0x1F && magic[1] == 0x8B:
+		return formatGzip
+	case bytes.Equal(magic[:4], []byte{0x28, 0xB5, 0x2F, 0xFD}):
+		return formatZstd
+	case bytes.Equal(magic[:4], []byte{0x0A, 0x0D, 0x0D, 0x0A}):
+		return formatPcapng
+	}
+	switch binary.LittleEndian.Uint32(magic) {
+	case 0xA1B2C3D4, 0xD4C3B2A1, 0xA1B23C4D, 0x4D3CB2A1:
+		return formatPcap
+	}
+	return formatUnknown
 }
 
 func (hdl *fileHandle) open(filename string) (e error) {
-	if hdl.file, e = os.Open(filename); e != nil {
+	hdl.filename = filename
+	if filename == "-" {
+		hdl.file = os.Stdin
+	} else if hdl.file, e = os.Open(filename); e != nil {
 		return e
 	}
-	pcapStream := io.Reader(hdl.file)
+	input := io.Reader(hdl.file)
+	if hdl.follow {
+		hdl.tail = &followFile{file: hdl.file, closing: &hdl.closing}
+		input = hdl.tail
+	}
 
-	ext := filepath.Ext(filename)
-	switch ext {
-	case ".gz":
-		if hdl.decompress, e = gzip.NewReader(hdl.file); e != nil {
+	// bufio.Reader allows peeking magic bytes on non-seekable input, such as stdin and named pipes
+	pcapStream := bufio.NewReader(input)
+	magic, _ := pcapStream.Peek(4)
+	format := detectFormat(magic)
+
+	switch format {
+	case formatGzip:
+		if hdl.decompress, e = gzip.NewReader(pcapStream); e != nil {
 			return e
 		}
-		pcapStream = hdl.decompress
-	case ".zst":
-		if pcapStream, e = zstd.NewReader(hdl.file); e != nil {
+	case formatZstd:
+		zr, e := zstd.NewReader(pcapStream)
+		if e != nil {
 			return e
 		}
-		hdl.decompress = io.NopCloser(pcapStream)
+		hdl.decompress = io.NopCloser(zr)
 	}
 
 	if hdl.decompress != nil {
-		filename = filename[:len(filename)-len(ext)]
-		ext = filepath.Ext(filename)
+		pcapStream = bufio.NewReader(hdl.decompress)
+		magic, _ = pcapStream.Peek(4)
+		format = detectFormat(magic)
 	}
 
-	switch ext {
-	case ".pcap":
+	switch format {
+	case formatPcap:
 		hdl.reader, e = pcapgo.NewReader(pcapStream)
-	case ".pcapng":
-		hdl.ngr, e = pcapgo.NewNgReader(pcapStream, pcapgo.NgReaderOptions{SkipUnknownVersion: true})
+	case formatPcapng:
+		hdl.idb = idbScanner{r: pcapStream}
+		hdl.ngr, e = pcapgo.NewNgReader(&hdl.idb, pcapgo.NgReaderOptions{SkipUnknownVersion: true})
 	default:
-		return errors.New("unknown file extension")
+		return errors.New("unknown file format")
+	}
+	if e != nil {
+		return e
 	}
-	return e
+
+	if hdl.reader != nil && len(hdl.namedLocals) > 0 {
+		return errors.New("local MAC address with interface name requires pcapng input, because pcap files do not contain interface names")
+	}
+	if hdl.locals.Size() == 0 && len(hdl.namedLocals) == 0 && !hdl.isNdndpdk() && (hdl.ngr == nil || !hdl.idb.HasMAC()) {
+		return errors.New("local MAC address is required because input file does not contain interface MAC addresses")
+	}
+	return nil
+}
+
+// isNdndpdk determines whether the input file is written by NDN-DPDK packet dumper.
+// In such a file, each face appears as an interface named "face%d" in LINUX_SLL link type,
+// in which the packet type field indicates traffic direction.
+func (hdl *fileHandle) isNdndpdk() bool {
+	return hdl.ngr != nil && hdl.ngr.SectionInfo().Application == ndndpdkApplication
 }
 
 func (hdl *fileHandle) Name() string {
@@ -64,27 +144,120 @@ func (hdl *fileHandle) Name() string {
 			return intf.Name
 		}
 	}
+	if hdl.file == nil {
+		return hdl.filename
+	}
 	return hdl.file.Name()
 }
 
-func (hdl *fileHandle) IsLocal(mac net.HardwareAddr) bool {
-	return macaddr.Equal(hdl.local, mac)
+func (hdl *fileHandle) IsLocal(ifindex int, mac net.HardwareAddr) bool {
+	if hdl.locals.Has([6]byte(mac)) {
+		return true
+	}
+	if hdl.ngr == nil {
+		return false
+	}
+
+	intf, e := hdl.ngr.Interface(ifindex)
+	if e != nil {
+		return false
+	}
+	if local, ok := hdl.namedLocals[intf.Name]; ok {
+		return macaddr.Equal(local, mac)
+	}
+	return macaddr.Equal(hdl.idb.MAC(ifindex, intf.Name), mac)
 }
 
-func (hdl *fileHandle) ZeroCopyReadPacketData() (wire []byte, ci gopacket.CaptureInfo, e error) {
+func (hdl *fileHandle) LinkType(ifindex int) layers.LinkType {
+	if hdl.reader != nil {
+		return hdl.reader.LinkType()
+	}
+	if hdl.ngr == nil {
+		return layers.LinkTypeNull
+	}
+	if intf, e := hdl.ngr.Interface(ifindex); e == nil {
+		return intf.LinkType
+	}
+	return layers.LinkTypeNull
+}
+
+func (hdl *fileHandle) FaceID(ifindex int) int {
+	if !hdl.isNdndpdk() {
+		return 0
+	}
+	intf, e := hdl.ngr.Interface(ifindex)
+	if e != nil {
+		return 0
+	}
+	if id, ok := strings.CutPrefix(intf.Name, "face"); ok {
+		faceID, _ := strconv.Atoi(id)
+		return faceID
+	}
+	return 0
+}
+
+func (hdl *fileHandle) readPacket() (wire []byte, ci gopacket.CaptureInfo, e error) {
 	if hdl.reader != nil {
 		return hdl.reader.ZeroCopyReadPacketData()
 	}
 	return hdl.ngr.ZeroCopyReadPacketData()
 }
 
-func (hdl *fileHandle) Close() error {
+func (hdl *fileHandle) ZeroCopyReadPacketData() (wire []byte, ci gopacket.CaptureInfo, e error) {
+	if !hdl.follow {
+		return hdl.readPacket()
+	}
+
+	hdl.mu.RLock()
+	defer hdl.mu.RUnlock()
+	for {
+		if hdl.closing.Load() {
+			return nil, gopacket.CaptureInfo{}, io.EOF
+		}
+		if hdl.failed != nil {
+			return nil, gopacket.CaptureInfo{}, hdl.failed
+		}
+
+		wire, ci, e = hdl.readPacket()
+		switch {
+		case !errors.Is(e, io.EOF) && !errors.Is(e, io.ErrUnexpectedEOF):
+			return
+		case hdl.closing.Load(), hdl.tail.next == "":
+			return nil, gopacket.CaptureInfo{}, io.EOF
+		}
+
+		// current file has been rotated; a partially written trailing record is discarded
+		next := hdl.tail.next
+		hdl.closeFile()
+		if e = hdl.open(next); e != nil {
+			hdl.closeFile()
+			hdl.failed = fmt.Errorf("follow %s: %w", next, e)
+			return nil, gopacket.CaptureInfo{}, hdl.failed
+		}
+	}
+}
+
+func (hdl *fileHandle) closeFile() error {
 	errs := []error{}
 	if hdl.decompress != nil {
 		errs = append(errs, hdl.decompress.Close())
+		hdl.decompress = nil
 	}
 	if hdl.file != nil {
 		errs = append(errs, hdl.file.Close())
+		hdl.file = nil
 	}
+	hdl.reader, hdl.ngr, hdl.tail = nil, nil, nil
 	return errors.Join(errs...)
 }
+
+func (hdl *fileHandle) Close() error {
+	if hdl.follow {
+		if wasClosed := hdl.closing.Swap(true); wasClosed {
+			return nil
+		}
+		hdl.mu.Lock()
+		defer hdl.mu.Unlock()
+	}
+	return hdl.closeFile()
+}
@@ -0,0 +1,284 @@
+package pcapinput_test
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndntdump/pcapinput"
+)
+
+type pcapngBuilder []byte
+
+func (b *pcapngBuilder) block(typ uint32, body []byte) {
+	length := uint32(12 + len(body))
+	*b = binary.LittleEndian.AppendUint32(*b, typ)
+	*b = binary.LittleEndian.AppendUint32(*b, length)
+	*b = append(*b, body...)
+	*b = binary.LittleEndian.AppendUint32(*b, length)
+}
+
+func (b *pcapngBuilder) SectionHeader() {
+	body := binary.LittleEndian.AppendUint32(nil, 0x1A2B3C4D)
+	body = binary.LittleEndian.AppendUint16(body, 1)
+	body = binary.LittleEndian.AppendUint16(body, 0)
+	body = binary.LittleEndian.AppendUint64(body, 0xFFFFFFFFFFFFFFFF)
+	b.block(0x0A0D0D0A, body)
+}
+
+func (b *pcapngBuilder) InterfaceDesc(name, mac string) {
+	body := binary.LittleEndian.AppendUint16(nil, 1) // LINKTYPE_ETHERNET
+	body = binary.LittleEndian.AppendUint16(body, 0)
+	body = binary.LittleEndian.AppendUint32(body, 0)
+	appendOption := func(code uint16, value []byte) {
+		body = binary.LittleEndian.AppendUint16(body, code)
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(value)))
+		body = append(body, value...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	if name != "" {
+		appendOption(2, []byte(name))
+	}
+	if mac != "" {
+		hwaddr, _ := net.ParseMAC(mac)
+		appendOption(6, hwaddr)
+	}
+	appendOption(0, nil)
+	b.block(0x00000001, body)
+}
+
+func (b *pcapngBuilder) EnhancedPacket(ifindex int, size int) {
+	body := binary.LittleEndian.AppendUint32(nil, uint32(ifindex))
+	body = binary.LittleEndian.AppendUint64(body, 0)
+	body = binary.LittleEndian.AppendUint32(body, uint32(size))
+	body = binary.LittleEndian.AppendUint32(body, uint32(size))
+	body = append(body, make([]byte, (size+3)&^3)...)
+	b.block(0x00000006, body)
+}
+
+func TestFileInterfaceMAC(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	var b pcapngBuilder
+	b.SectionHeader()
+	b.InterfaceDesc("eth0", "02:00:00:00:00:01")
+	b.InterfaceDesc("eth1", "02:00:00:00:00:02")
+	b.InterfaceDesc("eth2", "")
+	for range 500 {
+		b.EnhancedPacket(1, 60)
+	}
+	filename := filepath.Join(t.TempDir(), "input.pcapng")
+	require.NoError(os.WriteFile(filename, b, 0o644))
+
+	mac1, _ := net.ParseMAC("02:00:00:00:00:01")
+	mac2, _ := net.ParseMAC("02:00:00:00:00:02")
+	mac3, _ := net.ParseMAC("02:00:00:00:00:03")
+
+	hdl, e := pcapinput.Open("", filename, nil)
+	require.NoError(e)
+	nPackets := 0
+	for {
+		_, ci, e := hdl.ZeroCopyReadPacketData()
+		if e == io.EOF {
+			break
+		}
+		require.NoError(e)
+		assert.Equal(1, ci.InterfaceIndex)
+		nPackets++
+	}
+	assert.Equal(500, nPackets)
+	assert.True(hdl.IsLocal(0, mac1))
+	assert.False(hdl.IsLocal(0, mac2))
+	assert.True(hdl.IsLocal(1, mac2))
+	assert.False(hdl.IsLocal(2, mac3))
+	assert.NoError(hdl.Close())
+
+	hdl, e = pcapinput.Open("", filename, []string{"eth2=02:00:00:00:00:03", "eth0=02:00:00:00:00:02"})
+	require.NoError(e)
+	_, _, e = hdl.ZeroCopyReadPacketData()
+	require.NoError(e)
+	assert.False(hdl.IsLocal(0, mac1))
+	assert.True(hdl.IsLocal(0, mac2))
+	assert.True(hdl.IsLocal(2, mac3))
+	assert.NoError(hdl.Close())
+
+	var b2 pcapngBuilder
+	b2.SectionHeader()
+	b2.InterfaceDesc("eth0", "")
+	filename2 := filepath.Join(t.TempDir(), "input.pcapng")
+	require.NoError(os.WriteFile(filename2, b2, 0o644))
+	_, e = pcapinput.Open("", filename2, nil)
+	assert.Error(e)
+	hdl, e = pcapinput.Open("", filename2, []string{"02:00:00:00:00:03"})
+	require.NoError(e)
+	assert.True(hdl.IsLocal(0, mac3))
+	assert.NoError(hdl.Close())
+}
+
+func TestFileMagic(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	var b pcapngBuilder
+	b.SectionHeader()
+	b.InterfaceDesc("eth0", "02:00:00:00:00:01")
+	b.EnhancedPacket(0, 60)
+
+	filename := filepath.Join(t.TempDir(), "input.bin")
+	f, e := os.Create(filename)
+	require.NoError(e)
+	z := gzip.NewWriter(f)
+	z.Write(b)
+	require.NoError(z.Close())
+	require.NoError(f.Close())
+
+	hdl, e := pcapinput.Open("", filename, nil)
+	require.NoError(e)
+	wire, _, e := hdl.ZeroCopyReadPacketData()
+	assert.NoError(e)
+	assert.Len(wire, 60)
+	assert.NoError(hdl.Close())
+
+	require.NoError(os.WriteFile(filename, []byte("not a capture file"), 0o644))
+	_, e = pcapinput.Open("", filename, nil)
+	assert.Error(e)
+}
+
+func TestFilePcapNamedLocal(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	filename := filepath.Join(t.TempDir(), "input.pcap")
+	f, e := os.Create(filename)
+	require.NoError(e)
+	require.NoError(pcapgo.NewWriter(f).WriteFileHeader(65535, layers.LinkTypeEthernet))
+	require.NoError(f.Close())
+
+	// pcap file has no interface names
+	_, e = pcapinput.Open("", filename, []string{"eth0=02:00:00:00:00:01"})
+	assert.Error(e)
+
+	hdl, e := pcapinput.Open("", filename, []string{"02:00:00:00:00:01"})
+	require.NoError(e)
+	assert.NoError(hdl.Close())
+}
+
+func TestFollow(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	writePackets := func(filename string, flag int, header bool, sizes ...int) {
+		f, e := os.OpenFile(filename, flag|os.O_WRONLY, 0o644)
+		require.NoError(e)
+		defer f.Close()
+		w := pcapgo.NewWriter(f)
+		if header {
+			require.NoError(w.WriteFileHeader(65535, layers.LinkTypeEthernet))
+		}
+		for _, size := range sizes {
+			require.NoError(w.WritePacket(gopacket.CaptureInfo{CaptureLength: size, Length: size}, make([]byte, size)))
+		}
+	}
+
+	filename0, filename1 := filepath.Join(dir, "trace.pcap"), filepath.Join(dir, "trace.pcap1")
+	writePackets(filename0, os.O_CREATE, true, 100)
+	writePackets(filepath.Join(dir, "other.pcap"), os.O_CREATE, true, 400)
+
+	hdl, e := pcapinput.OpenFollow(filename0, []string{"02:00:00:00:00:01"})
+	require.NoError(e)
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		writePackets(filename0, os.O_APPEND, false, 101)
+		f, _ := os.OpenFile(filename0, os.O_APPEND|os.O_WRONLY, 0o644)
+		f.Write([]byte{0x01, 0x02, 0x03}) // partially written record
+		f.Close()
+		time.Sleep(300 * time.Millisecond)
+		writePackets(filename1, os.O_CREATE, true, 200)
+		time.Sleep(300 * time.Millisecond)
+		writePackets(filename1, os.O_APPEND, false, 201)
+		time.Sleep(600 * time.Millisecond)
+		hdl.Close()
+	}()
+
+	sizes := []int{}
+	for {
+		wire, _, e := hdl.ZeroCopyReadPacketData()
+		if e == io.EOF {
+			break
+		}
+		require.NoError(e)
+		sizes = append(sizes, len(wire))
+	}
+	assert.Equal([]int{100, 101, 200, 201}, sizes)
+}
+
+func TestFollowPcapng(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	filename0, filename1 := filepath.Join(dir, "trace.pcapng"), filepath.Join(dir, "trace.pcapng1")
+	var b0 pcapngBuilder
+	b0.SectionHeader()
+	b0.InterfaceDesc("eth0", "02:00:00:00:00:01")
+	b0.EnhancedPacket(0, 100)
+	b0.EnhancedPacket(0, 101)
+	b0 = b0[:len(b0)-40] // partially written block
+	require.NoError(os.WriteFile(filename0, b0, 0o644))
+	var b1 pcapngBuilder
+	b1.SectionHeader()
+	b1.InterfaceDesc("eth1", "02:00:00:00:00:02")
+	b1.EnhancedPacket(0, 200)
+	require.NoError(os.WriteFile(filename1, b1, 0o644))
+
+	hdl, e := pcapinput.OpenFollow(filename0, nil)
+	require.NoError(e)
+	defer hdl.Close()
+
+	wire, _, e := hdl.ZeroCopyReadPacketData()
+	require.NoError(e)
+	assert.Len(wire, 100)
+	assert.True(hdl.IsLocal(0, net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}))
+
+	wire, _, e = hdl.ZeroCopyReadPacketData()
+	require.NoError(e)
+	assert.Len(wire, 200)
+	assert.True(hdl.IsLocal(0, net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02}))
+	assert.Equal("eth1", hdl.Name())
+}
+
+func TestFollowOpenError(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	filename0, filename1 := filepath.Join(dir, "trace.pcapng"), filepath.Join(dir, "trace.pcapng1")
+	var b0 pcapngBuilder
+	b0.SectionHeader()
+	b0.InterfaceDesc("eth0", "02:00:00:00:00:01")
+	b0.EnhancedPacket(0, 100)
+	require.NoError(os.WriteFile(filename0, b0, 0o644))
+	require.NoError(os.WriteFile(filename1, []byte("not a capture file"), 0o644))
+
+	hdl, e := pcapinput.OpenFollow(filename0, nil)
+	require.NoError(e)
+	defer hdl.Close()
+
+	_, _, e = hdl.ZeroCopyReadPacketData()
+	require.NoError(e)
+	_, _, e = hdl.ZeroCopyReadPacketData()
+	assert.ErrorContains(e, filename1)
+	_, _, e = hdl.ZeroCopyReadPacketData()
+	assert.Error(e)
+	assert.NotPanics(func() { hdl.Name() })
+	assert.False(hdl.IsLocal(0, net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}))
+}
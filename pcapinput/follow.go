@@ -0,0 +1,112 @@
+package pcapinput
+
+import (
+	"cmp"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const followPollInterval = 200 * time.Millisecond
+
+// followFile reads a file that is being written.
+// Upon reaching end of file, it waits for more data instead of returning io.EOF.
+// io.EOF is returned only after the file has been superseded by a rotated file, or the handle is closing.
+type followFile struct {
+	file    *os.File
+	closing *atomic.Bool
+	next    string
+}
+
+func (f *followFile) Read(p []byte) (n int, e error) {
+	for {
+		if n, e = f.file.Read(p); n > 0 || e != io.EOF {
+			return n, e
+		}
+		if f.closing.Load() {
+			return 0, io.EOF
+		}
+
+		if f.next == "" {
+			f.next = nextRotatedFile(f.file.Name())
+		} else {
+			// the writer has moved on to next file, and the last read has drained this file
+			return 0, io.EOF
+		}
+		if f.next == "" {
+			time.Sleep(followPollInterval)
+		}
+	}
+}
+
+// nextRotatedFile finds the file that follows current in a rotation sequence.
+// Files in the same directory belong to the same sequence if their names are equal after removing digits,
+// such as trace.pcap, trace.pcap1, trace.pcap2 written by tcpdump -C,
+// or trace-20240102-030405.pcap written by tcpdump -G.
+// They are ordered by comparing digit sequences numerically.
+// Returns empty string if there is no next file.
+func nextRotatedFile(current string) (next string) {
+	dir, base := filepath.Split(current)
+	entries, e := os.ReadDir(cmp.Or(dir, "."))
+	if e != nil {
+		return ""
+	}
+
+	stem := rotationStem(base)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || rotationStem(name) != stem || compareNatural(name, base) <= 0 {
+			continue
+		}
+		if next == "" || compareNatural(name, next) < 0 {
+			next = name
+		}
+	}
+
+	if next == "" {
+		return ""
+	}
+	return filepath.Join(dir, next)
+}
+
+func rotationStem(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// compareNatural compares two strings, treating each sequence of digits as a number.
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da == 0 || db == 0 {
+			if c := cmp.Compare(a[0], b[0]); c != 0 {
+				return c
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		na, _ := strconv.ParseUint(a[:da], 10, 64)
+		nb, _ := strconv.ParseUint(b[:db], 10, 64)
+		if c := cmp.Compare(na, nb); c != 0 {
+			return c
+		}
+		a, b = a[da:], b[db:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func leadingDigits(s string) (n int) {
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
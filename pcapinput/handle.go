@@ -3,11 +3,15 @@ package pcapinput
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net"
+	"strings"
 
 	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
 	"github.com/usnistgov/ndn-dpdk/core/macaddr"
+	"github.com/zyedidia/generic/mapset"
 )
 
 // Handle represents a pcap input handle.
@@ -15,17 +19,29 @@ type Handle interface {
 	gopacket.ZeroCopyPacketDataSource
 	io.Closer
 	Name() string
-	IsLocal(mac net.HardwareAddr) bool
+
+	// IsLocal determines whether mac is a local MAC address on the interface that captured a packet.
+	// ifindex is the InterfaceIndex field of the packet's gopacket.CaptureInfo.
+	IsLocal(ifindex int, mac net.HardwareAddr) bool
+
+	// LinkType returns the link type of the interface that captured a packet.
+	LinkType(ifindex int) layers.LinkType
+
+	// FaceID returns the NDN-DPDK face ID of the interface that captured a packet.
+	// Returns zero if the packet was not captured from an NDN-DPDK face.
+	FaceID(ifindex int) int
 }
 
 // Open creates a pcap input handle.
 //
 //	ifname: network interface name.
-//	filename: input filename.
-//	local: local MAC address.
-func Open(ifname, filename, local string) (handle Handle, e error) {
+//	filename: input filename, or "-" for stdin; file format is detected from magic bytes.
+//	locals: local MAC addresses, each either "MAC" that applies to all interfaces,
+//	        or "ifname=MAC" that applies to a named interface in a pcapng file.
+//	        It may be omitted if a pcapng file contains if_MACaddr options.
+func Open(ifname, filename string, locals []string) (handle Handle, e error) {
 	if (ifname == "") == (filename == "") {
-		return nil, errors.New("exactly one of ifname and filename+local should be specified")
+		return nil, errors.New("exactly one of ifname and filename should be specified")
 	}
 
 	if ifname != "" {
@@ -36,14 +52,59 @@ func Open(ifname, filename, local string) (handle Handle, e error) {
 		return hdl, nil
 	}
 
-	localMAC, e := net.ParseMAC(local)
-	if e != nil || !macaddr.IsUnicast(localMAC) {
-		return nil, errors.New("invalid local MAC address")
+	hdl, e := newFileHandle(locals)
+	if e != nil {
+		return nil, e
+	}
+	if e = hdl.open(filename); e != nil {
+		hdl.Close()
+		return nil, e
+	}
+	return hdl, nil
+}
+
+// OpenFollow creates a pcap input handle that follows a file being written.
+// Upon reaching end of file, it waits for more packets to be appended.
+// When the writer rotates to a new file in the same directory, it continues with that file.
+//
+//	filename: input filename, which cannot be "-".
+//	locals: local MAC addresses, same as Open.
+func OpenFollow(filename string, locals []string) (handle Handle, e error) {
+	if filename == "" || filename == "-" {
+		return nil, errors.New("follow mode requires a filename")
+	}
+
+	hdl, e := newFileHandle(locals)
+	if e != nil {
+		return nil, e
 	}
-	hdl := &fileHandle{local: localMAC}
+	hdl.follow = true
 	if e = hdl.open(filename); e != nil {
 		hdl.Close()
 		return nil, e
 	}
 	return hdl, nil
 }
+
+func newFileHandle(locals []string) (hdl *fileHandle, e error) {
+	hdl = &fileHandle{
+		locals:      mapset.New[[6]byte](),
+		namedLocals: map[string]net.HardwareAddr{},
+	}
+	for _, local := range locals {
+		name, addr, hasName := strings.Cut(local, "=")
+		if !hasName {
+			name, addr = "", local
+		}
+		localMAC, e := net.ParseMAC(addr)
+		if e != nil || !macaddr.IsUnicast(localMAC) {
+			return nil, fmt.Errorf("invalid local MAC address %s", local)
+		}
+		if hasName {
+			hdl.namedLocals[name] = localMAC
+		} else {
+			hdl.locals.Put([6]byte(localMAC))
+		}
+	}
+	return hdl, nil
+}
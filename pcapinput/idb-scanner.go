@@ -0,0 +1,173 @@
+package pcapinput
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+const (
+	ngBlockSectionHeader    = 0x0A0D0D0A
+	ngBlockInterfaceDesc    = 0x00000001
+	ngByteOrderMagic        = 0x1A2B3C4D
+	ngOptionEndOfOpt        = 0
+	ngOptionIfName          = 2
+	ngOptionIfMACAddr       = 6
+	ngMaxBufferedBlockBytes = 1 << 20
+)
+
+type idbInfo struct {
+	Name string
+	MAC  net.HardwareAddr
+}
+
+// idbScanner observes a pcapng stream and collects interface information from Interface Description Blocks.
+// pcapgo.NgReader does not expose the if_MACaddr option, so that it is parsed here.
+type idbScanner struct {
+	r       io.Reader
+	order   binary.ByteOrder
+	pending []byte
+	skip    int
+	broken  bool
+
+	section []idbInfo
+	byName  map[string]net.HardwareAddr
+}
+
+func (s *idbScanner) Read(p []byte) (n int, e error) {
+	n, e = s.r.Read(p)
+	if !s.broken {
+		s.feed(p[:n])
+	}
+	return
+}
+
+func (s *idbScanner) feed(b []byte) {
+	for len(b) > 0 && !s.broken {
+		if s.skip > 0 {
+			n := min(s.skip, len(b))
+			s.skip -= n
+			b = b[n:]
+			continue
+		}
+
+		// every block is at least 12 octets: type, length, and either byte-order magic or trailing length
+		need := 12
+		if len(s.pending) >= need {
+			need = int(s.order.Uint32(s.pending[4:8]))
+		}
+		n := min(need-len(s.pending), len(b))
+		s.pending = append(s.pending, b[:n]...)
+		b = b[n:]
+		if len(s.pending) < need {
+			continue
+		}
+
+		if need == 12 {
+			if !s.readBlockHeader() {
+				s.broken = true
+				return
+			}
+			if len(s.pending) == 0 || len(s.pending) < int(s.order.Uint32(s.pending[4:8])) {
+				continue
+			}
+		}
+		s.readBlock()
+		s.pending = s.pending[:0]
+	}
+}
+
+// readBlockHeader processes the first 12 octets of a block.
+// Returns false if the stream cannot be parsed.
+func (s *idbScanner) readBlockHeader() bool {
+	if typ := binary.LittleEndian.Uint32(s.pending); typ == ngBlockSectionHeader {
+		switch {
+		case binary.LittleEndian.Uint32(s.pending[8:]) == ngByteOrderMagic:
+			s.order = binary.LittleEndian
+		case binary.BigEndian.Uint32(s.pending[8:]) == ngByteOrderMagic:
+			s.order = binary.BigEndian
+		default:
+			return false
+		}
+	} else if s.order == nil {
+		return false
+	}
+
+	length := int(s.order.Uint32(s.pending[4:8]))
+	if length < 12 || length%4 != 0 {
+		return false
+	}
+
+	switch s.order.Uint32(s.pending) {
+	case ngBlockSectionHeader:
+		return length >= 28 && length <= ngMaxBufferedBlockBytes
+	case ngBlockInterfaceDesc:
+		return length >= 20 && length <= ngMaxBufferedBlockBytes
+	default:
+		s.skip = length - len(s.pending)
+		s.pending = s.pending[:0]
+		return true
+	}
+}
+
+func (s *idbScanner) readBlock() {
+	switch s.order.Uint32(s.pending) {
+	case ngBlockSectionHeader:
+		s.section = s.section[:0]
+	case ngBlockInterfaceDesc:
+		length := s.order.Uint32(s.pending[4:8])
+		s.readInterfaceDesc(s.pending[16 : length-4])
+	}
+}
+
+func (s *idbScanner) readInterfaceDesc(options []byte) {
+	var intf idbInfo
+	for len(options) >= 4 {
+		code, length := s.order.Uint16(options), int(s.order.Uint16(options[2:]))
+		options = options[4:]
+		if code == ngOptionEndOfOpt || len(options) < length {
+			break
+		}
+
+		value := options[:length]
+		switch code {
+		case ngOptionIfName:
+			intf.Name = string(value)
+		case ngOptionIfMACAddr:
+			if length == 6 {
+				intf.MAC = net.HardwareAddr(append([]byte{}, value...))
+			}
+		}
+		options = options[min((length+3)&^3, len(options)):]
+	}
+
+	s.section = append(s.section, intf)
+	if intf.Name != "" && intf.MAC != nil {
+		if s.byName == nil {
+			s.byName = map[string]net.HardwareAddr{}
+		}
+		s.byName[intf.Name] = intf.MAC
+	}
+}
+
+// HasMAC determines whether any interface MAC address has been found.
+func (s *idbScanner) HasMAC() bool {
+	for _, intf := range s.section {
+		if intf.MAC != nil {
+			return true
+		}
+	}
+	return len(s.byName) > 0
+}
+
+// MAC returns MAC address of an interface.
+// Interfaces are matched by name if available, because the scanner may be ahead of the packet being processed.
+func (s *idbScanner) MAC(index int, name string) net.HardwareAddr {
+	if name != "" {
+		return s.byName[name]
+	}
+	if index >= 0 && index < len(s.section) {
+		return s.section[index].MAC
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gopacket/gopacket"
 	"github.com/gopacket/gopacket/afpacket"
+	"github.com/gopacket/gopacket/layers"
 	"github.com/usnistgov/ndn-dpdk/core/macaddr"
 	"github.com/zyedidia/generic/mapset"
 )
@@ -53,10 +54,18 @@ func (hdl *netifHandle) Name() string {
 	return hdl.ifname
 }
 
-func (hdl *netifHandle) IsLocal(mac net.HardwareAddr) bool {
+func (hdl *netifHandle) IsLocal(ifindex int, mac net.HardwareAddr) bool {
 	return hdl.locals.Has([6]byte(mac))
 }
 
+func (hdl *netifHandle) LinkType(ifindex int) layers.LinkType {
+	return layers.LinkTypeEthernet
+}
+
+func (hdl *netifHandle) FaceID(ifindex int) int {
+	return 0
+}
+
 func (hdl *netifHandle) ZeroCopyReadPacketData() (wire []byte, ci gopacket.CaptureInfo, e error) {
 	hdl.mu.RLock()
 	defer hdl.mu.RUnlock()
@@ -0,0 +1,174 @@
+package ndntdump
+
+import (
+	"math"
+
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
+)
+
+// Classifier recognizes an application protocol and saves protocol-specific fields on the Record.
+// It is invoked on Interest, Data, and Nack packets before payload zeroization.
+// Returns true if the packet is recognized, so that remaining classifiers are skipped.
+type Classifier func(rec *Record, pkt *ndn.Packet) bool
+
+// NFD ControlParameters TLV-TYPE numbers.
+const (
+	TtControlParameters = 0x68
+	ttCpFaceID          = 0x69
+	ttCpURI             = 0x72
+	ttCpLocalURI        = 0x81
+	ttCpOrigin          = 0x6F
+	ttCpCost            = 0x6A
+	ttCpCapacity        = 0x83
+	ttCpCount           = 0x84
+	ttCpFlags           = 0x6C
+	ttCpMask            = 0x70
+	ttCpStrategy        = 0x6B
+	ttCpExpiration      = 0x6D
+	ttCpFacePersistency = 0x85
+	ttCpMtu             = 0x89
+)
+
+// ControlParameters contains NFD management ControlParameters.
+type ControlParameters struct {
+	Name            ndn.Name `json:"name,omitempty"`
+	FaceID          int      `json:"faceId,omitempty"`
+	URI             string   `json:"uri,omitempty"`
+	LocalURI        string   `json:"localUri,omitempty"`
+	Origin          int      `json:"origin,omitempty"`
+	Cost            int      `json:"cost,omitempty"`
+	Capacity        int      `json:"capacity,omitempty"`
+	Count           int      `json:"count,omitempty"`
+	Flags           int      `json:"flags,omitempty"`
+	Mask            int      `json:"mask,omitempty"`
+	Strategy        ndn.Name `json:"strategy,omitempty"`
+	Expiration      int      `json:"expiration,omitempty"` // ExpirationPeriod (ms)
+	FacePersistency int      `json:"facePersistency,omitempty"`
+	Mtu             int      `json:"mtu,omitempty"`
+}
+
+// UnmarshalTLV decodes ControlParameters element.
+// Unrecognized fields are skipped.
+func (cp *ControlParameters) UnmarshalTLV(typ uint32, value []byte) (e error) {
+	if typ != TtControlParameters {
+		return tlv.ErrType
+	}
+
+	*cp = ControlParameters{}
+	d := tlv.DecodingBuffer(value)
+	for de := range d.IterElements() {
+		nni := func() int {
+			return int(de.UnmarshalNNI(math.MaxInt64, &e, tlv.ErrRange))
+		}
+		switch de.Type {
+		case an.TtName:
+			e = cp.Name.UnmarshalBinary(de.Value)
+		case ttCpFaceID:
+			cp.FaceID = nni()
+		case ttCpURI:
+			cp.URI = string(de.Value)
+		case ttCpLocalURI:
+			cp.LocalURI = string(de.Value)
+		case ttCpOrigin:
+			cp.Origin = nni()
+		case ttCpCost:
+			cp.Cost = nni()
+		case ttCpCapacity:
+			cp.Capacity = nni()
+		case ttCpCount:
+			cp.Count = nni()
+		case ttCpFlags:
+			cp.Flags = nni()
+		case ttCpMask:
+			cp.Mask = nni()
+		case ttCpStrategy:
+			d1 := tlv.DecodingBuffer(de.Value)
+			for de1 := range d1.IterElements() {
+				if de1.Type == an.TtName {
+					e = cp.Strategy.UnmarshalBinary(de1.Value)
+				}
+			}
+		case ttCpExpiration:
+			cp.Expiration = nni()
+		case ttCpFacePersistency:
+			cp.FacePersistency = nni()
+		case ttCpMtu:
+			cp.Mtu = nni()
+		}
+		if e != nil {
+			return e
+		}
+	}
+	return d.ErrUnlessEOF()
+}
+
+// TLV-TYPE numbers of State Vector Sync.
+const (
+	TtStateVector = 0xC9
+
+	ttSvEntry     = 0xCA
+	ttSvSeqNo     = 0xCC
+	ttSvSeqNoEnt  = 0xD2
+	ttSvBootstrap = 0xD4
+	ttSvSeqNo3    = 0xD6
+)
+
+// SVEntry is an entry in State Vector Sync state vector.
+type SVEntry struct {
+	Node ndn.Name `json:"node"`           // node name
+	Seq  uint64   `json:"seq"`            // sequence number
+	Boot uint64   `json:"boot,omitempty"` // bootstrap time (SVS v3)
+}
+
+// StateVector is a State Vector Sync state vector.
+type StateVector []SVEntry
+
+// UnmarshalTLV decodes StateVector element.
+// Both SVS v2 format (one SeqNo per node) and SVS v3 format (SeqNoEntry per bootstrap time) are accepted.
+func (sv *StateVector) UnmarshalTLV(typ uint32, value []byte) (e error) {
+	if typ != TtStateVector {
+		return tlv.ErrType
+	}
+
+	*sv = StateVector{}
+	d := tlv.DecodingBuffer(value)
+	for de := range d.IterElements() {
+		if de.Type != ttSvEntry {
+			continue
+		}
+		var node ndn.Name
+		d1 := tlv.DecodingBuffer(de.Value)
+		for de1 := range d1.IterElements() {
+			switch de1.Type {
+			case an.TtName:
+				e = node.UnmarshalBinary(de1.Value)
+			case ttSvSeqNo:
+				*sv = append(*sv, SVEntry{Node: node, Seq: de1.UnmarshalNNI(math.MaxUint64, &e, tlv.ErrRange)})
+			case ttSvSeqNoEnt:
+				ent := SVEntry{Node: node}
+				d2 := tlv.DecodingBuffer(de1.Value)
+				for de2 := range d2.IterElements() {
+					switch de2.Type {
+					case ttSvBootstrap:
+						ent.Boot = de2.UnmarshalNNI(math.MaxUint64, &e, tlv.ErrRange)
+					case ttSvSeqNo3:
+						ent.Seq = de2.UnmarshalNNI(math.MaxUint64, &e, tlv.ErrRange)
+					}
+				}
+				if e == nil {
+					e = d2.ErrUnlessEOF()
+				}
+				*sv = append(*sv, ent)
+			}
+			if e != nil {
+				return e
+			}
+		}
+		if e = d1.ErrUnlessEOF(); e != nil {
+			return e
+		}
+	}
+	return d.ErrUnlessEOF()
+}
@@ -47,16 +47,27 @@ func saveFlowPorts[P ~uint8, N ~uint16](flow []byte, dir Direction, proto P, src
 // Reader reads NDN packets from ZeroCopyPacketDataSource.
 type Reader struct {
 	src            gopacket.ZeroCopyPacketDataSource
-	isLocal        func(net.HardwareAddr) bool
+	isLocal        func(ifindex int, mac net.HardwareAddr) bool
+	linkType       func(ifindex int) layers.LinkType
+	faceID         func(ifindex int) int
 	tcpPort        layers.TCPPort
 	wssPort        layers.TCPPort
 	anon           *Anonymizer
 	zeroizePayload bool
+	nameComps      bool
+	namePrefixLen  int
+	diagnostics    bool
+	diagNotNDN     bool
+	flowInfo       bool
+	classifiers    []Classifier
+	diagCounters   map[DiagReason]uint64
 
 	dlp     *gopacket.DecodingLayerParser
+	dlpSLL  *gopacket.DecodingLayerParser
 	dlpTLV  *gopacket.DecodingLayerParser
 	decoded []gopacket.LayerType
 	eth     layers.Ethernet
+	sll     layers.LinuxSLL
 	ip4     layers.IPv4
 	ip6     layers.IPv6
 	udp     layers.UDP
@@ -78,14 +89,33 @@ func (r *Reader) Read() (rec Record, e error) {
 		return
 	}
 
+	var reason DiagReason
 RETRY:
 	rec = Record{}
 	if rec.Wire, rec.CaptureInfo, e = r.src.ZeroCopyReadPacketData(); e != nil {
 		return
 	}
 
-	if e = r.dlp.DecodeLayers(rec.Wire, &r.decoded); e != nil {
-		goto RETRY
+	rec.LinkType = layers.LinkTypeEthernet
+	if r.linkType != nil {
+		rec.LinkType = r.linkType(rec.CaptureInfo.InterfaceIndex)
+	}
+	if r.faceID != nil {
+		rec.Face = r.faceID(rec.CaptureInfo.InterfaceIndex)
+	}
+
+	switch rec.LinkType {
+	case layers.LinkTypeEthernet:
+		e = r.dlp.DecodeLayers(rec.Wire, &r.decoded)
+	case layers.LinkTypeLinuxSLL:
+		e = r.dlpSLL.DecodeLayers(rec.Wire, &r.decoded)
+	default:
+		reason = DiagLinkType
+		goto DROP
+	}
+	if e != nil {
+		reason = r.decodeErrorReason()
+		goto DROP
 	}
 
 	for _, layerType := range r.decoded {
@@ -100,19 +130,35 @@ RETRY:
 					case r.tcp.DstPort == r.tcpPort, r.tcp.DstPort == r.wssPort:
 						r.dir = DirectionRX
 					default:
-						goto RETRY
+						reason = DiagNotNDN
+						goto DROP
 					}
 				}
-			case r.isLocal(r.eth.SrcMAC):
+			case r.isLocal(rec.CaptureInfo.InterfaceIndex, r.eth.SrcMAC):
 				r.dir = DirectionTX
-			case r.isLocal(r.eth.DstMAC):
+			case r.isLocal(rec.CaptureInfo.InterfaceIndex, r.eth.DstMAC):
 				r.dir = DirectionRX
 			default:
-				goto RETRY
+				reason = r.unknownDirectionReason()
+				goto DROP
 			}
 			r.anon.AnonymizeMAC(r.eth.SrcMAC)
 			r.anon.AnonymizeMAC(r.eth.DstMAC)
 			rec.Flow = saveFlowAddrs(make([]byte, 0, 12), r.dir, r.eth.SrcMAC, r.eth.DstMAC)
+		case layers.LayerTypeLinuxSLL:
+			switch r.sll.PacketType {
+			case layers.LinuxSLLPacketTypeHost, layers.LinuxSLLPacketTypeBroadcast, layers.LinuxSLLPacketTypeMulticast:
+				r.dir = DirectionRX
+			case layers.LinuxSLLPacketTypeOutgoing:
+				r.dir = DirectionTX
+			default:
+				reason = r.unknownDirectionReason()
+				goto DROP
+			}
+			if len(r.sll.Addr) > 0 {
+				r.anon.AnonymizeMAC(r.sll.Addr)
+				rec.Flow = append(make([]byte, 0, 8), r.sll.Addr...)
+			}
 		case layers.LayerTypeIPv4:
 			r.anon.AnonymizeIP(r.ip4.SrcIP)
 			r.anon.AnonymizeIP(r.ip4.DstIP)
@@ -127,31 +173,47 @@ RETRY:
 			rec.Flow = saveFlowPorts(rec.Flow, r.dir, layers.IPProtocolTCP, r.tcp.SrcPort, r.tcp.DstPort)
 			switch {
 			case r.tcp.SrcPort == r.wssPort, r.tcp.DstPort == r.wssPort:
-				r.readWebSocket(rec.CaptureInfo, rec.Flow)
+				r.readWebSocket(rec.CaptureInfo, rec.LinkType, rec.Face, rec.Flow)
 			case r.tcp.SrcPort == r.tcpPort, r.tcp.DstPort == r.tcpPort:
 			default:
-				goto RETRY
+				reason = DiagNotNDN
+				goto DROP
 			}
 			return rec, nil
 		case ndnlayer.LayerTypeTLV:
 			rec.Size2 = len(r.tlv.LayerContents())
 		case ndnlayer.LayerTypeNDN:
-			if r.readPacket(&rec) {
-				return rec, nil
+			ok, fragErr := r.readPacket(&rec)
+			if !ok {
+				reason = DiagNDN
+				goto DROP
+			}
+			if fragErr != nil {
+				r.unread = r.appendDiag(r.unread, rec.CaptureInfo, rec.Face, DiagFragment, fragErr)
 			}
+			rec.SnapLen = snapLen(rec.Wire, r.tlv.LayerContents())
+			return rec, nil
 		}
 	}
+	reason = DiagNotNDN
 
+DROP:
+	if d, ok := r.diag(rec.CaptureInfo, rec.Face, reason, e); ok {
+		return d, nil
+	}
 	goto RETRY
 }
 
-func (r *Reader) readWebSocket(ci gopacket.CaptureInfo, flow []byte) {
+func (r *Reader) readWebSocket(ci gopacket.CaptureInfo, linkType layers.LinkType, face int, flow []byte) {
 	if len(r.tcp.Payload) == 0 {
 		return
 	}
 
-	frames, _ := websocket.ExtractBinaryFrames(r.tcp.Payload)
+	frames, e := websocket.ExtractBinaryFrames(r.tcp.Payload)
 	if len(frames) == 0 {
+		if e != nil && !websocket.IsHandshake(r.tcp.Payload) {
+			r.unread = r.appendDiag(r.unread, ci, face, DiagWebSocket, e)
+		}
 		websocket.AnonymizeXForwardedFor(r.tcp.Payload)
 		return
 	}
@@ -159,59 +221,88 @@ func (r *Reader) readWebSocket(ci gopacket.CaptureInfo, flow []byte) {
 	r.unread = make([]Record, 0, len(frames))
 	for _, f := range frames {
 		if e := r.dlpTLV.DecodeLayers(f.Payload, &r.decoded); e != nil {
+			r.unread = r.appendDiag(r.unread, ci, face, r.decodeErrorReason(), e)
 			continue
 		}
 
-		rec := Record{CaptureInfo: ci, Flow: flow}
+		rec := Record{CaptureInfo: ci, LinkType: linkType, Face: face, Flow: flow}
 		for _, layerType := range r.decoded {
 			switch layerType {
 			case ndnlayer.LayerTypeTLV:
 				rec.Size2 = len(r.tlv.LayerContents())
 			case ndnlayer.LayerTypeNDN:
-				if r.readPacket(&rec) {
-					r.unread = append(r.unread, rec)
+				ok, fragErr := r.readPacket(&rec)
+				if !ok {
+					r.unread = r.appendDiag(r.unread, ci, face, DiagNDN, nil)
+					continue
+				}
+				if rec.FlowInfo != nil {
+					rec.FlowInfo.WebSocket = true
+				}
+				r.unread = append(r.unread, rec)
+				if fragErr != nil {
+					r.unread = r.appendDiag(r.unread, ci, face, DiagFragment, fragErr)
 				}
 			}
 		}
 	}
+	if e != nil {
+		r.unread = r.appendDiag(r.unread, ci, face, DiagWebSocket, e)
+	}
 }
 
-func (r *Reader) readPacket(rec *Record) bool {
+// readPacket saves NDN packet fields on rec.
+// ok indicates whether rec contains an NDN packet.
+// fragErr indicates that the first fragment does not contain a decodable name.
+func (r *Reader) readPacket(rec *Record) (ok bool, fragErr error) {
 	pkt := r.ndn.Packet
 	var pktType PktType
 	switch {
 	case pkt == nil:
-		return false
+		return false, nil
 	case pkt.Fragment != nil:
 		pktType = PktTypeFragment
 	case pkt.Interest != nil:
 		pktType = PktTypeInterest
 		rec.SaveInterest(*pkt.Interest, an.NackNone)
-		if r.zeroizePayload {
-			zeroizeInterestPayload(pkt.Interest)
-		}
 	case pkt.Data != nil:
 		pktType = PktTypeData
 		rec.SaveData(*pkt.Data)
-		if r.zeroizePayload {
-			zeroizeDataPayload(pkt.Data)
-		}
 	case pkt.Nack != nil:
 		pktType = PktTypeNack
 		rec.SaveInterest(pkt.Nack.Interest, pkt.Nack.Reason)
-		if r.zeroizePayload {
+	default:
+		return false, nil
+	}
+
+	if pktType != PktTypeFragment {
+		for _, classify := range r.classifiers {
+			if classify(rec, pkt) {
+				break
+			}
+		}
+	}
+
+	if r.zeroizePayload {
+		switch pktType {
+		case PktTypeInterest:
+			zeroizeInterestPayload(pkt.Interest)
+		case PktTypeData:
+			zeroizeDataPayload(pkt.Data)
+		case PktTypeNack:
 			zeroizeInterestPayload(&pkt.Nack.Interest)
 		}
-	default:
-		return false
 	}
 
 	rec.DirType = string(r.dir) + string(pktType)
 	rec.Timestamp = rec.CaptureInfo.Timestamp.UnixNano()
+	if r.tlv.Element.Type == an.TtLpPacket {
+		rec.SaveLpHeader(r.tlv.Element.Value)
+	}
 
 	if frag := pkt.Fragment; frag != nil {
 		if frag.FragIndex == 0 {
-			r.readFragment(pkt.Lp, *frag, rec)
+			fragErr = r.readFragment(pkt.Lp, *frag, rec)
 		}
 	} else {
 		switch r.tlv.Element.Type {
@@ -226,19 +317,26 @@ func (r *Reader) readPacket(rec *Record) bool {
 			}
 		}
 	}
-	return true
+
+	rec.saveNameInfo(r.nameComps, r.namePrefixLen)
+	if r.flowInfo {
+		rec.saveFlowInfo(r.dir)
+	}
+	return true, fragErr
 }
 
-func (Reader) readFragment(lpl3 ndn.LpL3, frag ndn.LpFragment, rec *Record) {
+// readFragment saves L3 fields from the first fragment.
+// Returns an error if the fragment does not contain a decodable name.
+func (Reader) readFragment(lpl3 ndn.LpL3, frag ndn.LpFragment, rec *Record) (e error) {
 	var payload incompleteTLV
-	if _, e := payload.Decode(frag.Payload); e != nil {
-		return
+	if _, e = payload.Decode(frag.Payload); e != nil {
+		return e
 	}
 
 	switch payload.Type {
 	case an.TtInterest:
 		var interest ndn.Interest
-		interest.UnmarshalBinary(payload.Value) // ignore error
+		e = interest.UnmarshalBinary(payload.Value)
 		if lpl3.NackReason == an.NackNone {
 			rec.DirType += string(PktTypeInterest)
 		} else {
@@ -247,11 +345,18 @@ func (Reader) readFragment(lpl3 ndn.LpL3, frag ndn.LpFragment, rec *Record) {
 		rec.SaveInterest(interest, lpl3.NackReason)
 	case an.TtData:
 		var data ndn.Data
-		data.UnmarshalBinary(payload.Value) // ignore error
+		e = data.UnmarshalBinary(payload.Value)
 		rec.DirType += string(PktTypeData)
 		rec.SaveData(data)
+		if e != nil {
+			rec.Digest = nil // incomplete Data has no meaningful digest
+		}
 	}
 	rec.Size3 = payload.Size
+	if len(rec.Name) > 0 {
+		return nil
+	}
+	return e
 }
 
 // NewReader creates Reader.
@@ -259,10 +364,19 @@ func NewReader(src gopacket.ZeroCopyPacketDataSource, opts ReaderOptions) (r *Re
 	r = &Reader{
 		src:            src,
 		isLocal:        opts.IsLocal,
+		linkType:       opts.LinkType,
+		faceID:         opts.FaceID,
 		tcpPort:        layers.TCPPort(opts.TCPPort),
 		wssPort:        layers.TCPPort(opts.WebSocketPort),
 		anon:           opts.Anonymizer,
 		zeroizePayload: !opts.KeepPayload,
+		nameComps:      opts.NameComponents,
+		namePrefixLen:  opts.NamePrefixLen,
+		diagnostics:    opts.Diagnostics,
+		diagNotNDN:     opts.DiagnosticsNotNDN,
+		flowInfo:       opts.FlowInfo,
+		classifiers:    opts.Classifiers,
+		diagCounters:   map[DiagReason]uint64{},
 	}
 	if r.wssPort == 0 {
 		r.wssPort = 9696
@@ -270,6 +384,8 @@ func NewReader(src gopacket.ZeroCopyPacketDataSource, opts ReaderOptions) (r *Re
 
 	r.dlp = gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &r.eth, &r.ip4, &r.ip6, &r.udp, &r.tcp, &r.tlv, &r.ndn)
 	r.dlp.IgnoreUnsupported = true
+	r.dlpSLL = gopacket.NewDecodingLayerParser(layers.LayerTypeLinuxSLL, &r.sll, &r.ip4, &r.ip6, &r.udp, &r.tcp, &r.tlv, &r.ndn)
+	r.dlpSLL.IgnoreUnsupported = true
 	r.dlpTLV = gopacket.NewDecodingLayerParser(ndnlayer.LayerTypeTLV, &r.tlv, &r.ndn)
 	r.dlpTLV.IgnoreUnsupported = true
 	return r
@@ -277,11 +393,20 @@ func NewReader(src gopacket.ZeroCopyPacketDataSource, opts ReaderOptions) (r *Re
 
 // ReaderOptions passes options to NewReader.
 type ReaderOptions struct {
-	IsLocal       func(net.HardwareAddr) bool
+	IsLocal       func(ifindex int, mac net.HardwareAddr) bool
+	LinkType      func(ifindex int) layers.LinkType // default is Ethernet
+	FaceID        func(ifindex int) int             // NDN-DPDK face ID
 	TCPPort       int
 	WebSocketPort int
 	Anonymizer    *Anonymizer
 	KeepPayload   bool
+
+	NameComponents    bool // save structured name components
+	NamePrefixLen     int  // save name prefix truncated to this many components
+	Diagnostics       bool // return diagnostic records for dropped and partially parsed frames
+	DiagnosticsNotNDN bool // also return diagnostic records for frames that do not carry NDN traffic
+	FlowInfo          bool // save structured flow key and flow ID
+	Classifiers       []Classifier
 }
 
 type incompleteTLV struct {
@@ -0,0 +1,348 @@
+package ndntdump_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/fileoutput"
+	"github.com/usnistgov/ndntdump/pcapinput"
+)
+
+// makeSLL creates a LINUX_SLL frame in the format written by NDN-DPDK packet dumper.
+// If l3 is nil, only the header is returned.
+func makeSLL(pktType layers.LinuxSLLPacketType, l3 tlv.Fielder) []byte {
+	var wire []byte
+	if l3 != nil {
+		wire, _ = tlv.EncodeFrom(l3)
+	}
+	return append([]byte{
+		0x00, byte(pktType), // packet type
+		0xFF, 0xFF, // ARPHRD type
+		0x00, 0x00, // address length
+		0, 0, 0, 0, 0, 0, 0, 0, // address
+		0x86, 0x24, // protocol
+	}, wire...)
+}
+
+func readAllRecords(t testing.TB, input pcapinput.Handle, opts ndntdump.ReaderOptions) (records []ndntdump.Record) {
+	opts.IsLocal, opts.LinkType, opts.FaceID = input.IsLocal, input.LinkType, input.FaceID
+	if opts.Anonymizer == nil {
+		opts.Anonymizer = ndntdump.NewAnonymizer(nil, true, nil)
+	}
+	reader := ndntdump.NewReader(input, opts)
+	for {
+		rec, e := reader.Read()
+		if e == io.EOF {
+			return
+		}
+		require.NoError(t, e)
+		rec.Wire = bytes.Clone(rec.Wire)
+		records = append(records, rec)
+	}
+}
+
+func TestReaderNdndpdk(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	var b bytes.Buffer
+	opts := pcapgo.DefaultNgWriterOptions
+	opts.SectionInfo.Application = "NDN-DPDK"
+	w, e := pcapgo.NewNgWriterInterface(&b, pcapgo.NgInterface{Name: "face4001", LinkType: layers.LinkTypeLinuxSLL}, opts)
+	require.NoError(e)
+	intf1, e := w.AddInterface(pcapgo.NgInterface{Name: "face4002", LinkType: layers.LinkTypeLinuxSLL})
+	require.NoError(e)
+
+	ts := time.Unix(1700000000, 0)
+	writePacket := func(intf int, wire []byte) {
+		require.NoError(w.WritePacket(gopacket.CaptureInfo{
+			Timestamp:      ts,
+			CaptureLength:  len(wire),
+			Length:         len(wire),
+			InterfaceIndex: intf,
+		}, wire))
+	}
+	writePacket(0, makeSLL(layers.LinuxSLLPacketTypeHost, ndn.MakeInterest("/A")))
+	writePacket(intf1, makeSLL(layers.LinuxSLLPacketTypeOutgoing, ndn.MakeInterest("/A")))
+	writePacket(intf1, makeSLL(layers.LinuxSLLPacketTypeHost, ndn.MakeData("/A")))
+	writePacket(0, makeSLL(layers.LinuxSLLPacketTypeOutgoing, ndn.MakeData("/A")))
+	require.NoError(w.Flush())
+
+	filename := filepath.Join(t.TempDir(), "pdump.pcapng")
+	require.NoError(os.WriteFile(filename, b.Bytes(), 0o644))
+	input, e := pcapinput.Open("", filename, nil)
+	require.NoError(e)
+	defer input.Close()
+
+	records := readAllRecords(t, input, ndntdump.ReaderOptions{})
+	require.Len(records, 4)
+	for i, expect := range []struct {
+		DirType string
+		Face    int
+	}{
+		{">I", 4001},
+		{"<I", 4002},
+		{">D", 4002},
+		{"<D", 4001},
+	} {
+		assert.Equal(expect.DirType, records[i].DirType, i)
+		assert.Equal(expect.Face, records[i].Face, i)
+		assert.Equal(layers.LinkTypeLinuxSLL, records[i].LinkType, i)
+		assert.Equal("/8=A", records[i].Name.String(), i)
+	}
+}
+
+// writeNdndpdkTrace writes SLL frames into a pcapng file in NDN-DPDK packet dumper format, on face 1.
+func writeNdndpdkTrace(t testing.TB, frames ...[]byte) (filename string) {
+	var b bytes.Buffer
+	opts := pcapgo.DefaultNgWriterOptions
+	opts.SectionInfo.Application = "NDN-DPDK"
+	w, e := pcapgo.NewNgWriterInterface(&b, pcapgo.NgInterface{Name: "face1", LinkType: layers.LinkTypeLinuxSLL}, opts)
+	require.NoError(t, e)
+	for _, wire := range frames {
+		require.NoError(t, w.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     time.Unix(1700000000, 0),
+			CaptureLength: len(wire),
+			Length:        len(wire),
+		}, wire))
+	}
+	require.NoError(t, w.Flush())
+
+	filename = filepath.Join(t.TempDir(), "pdump.pcapng")
+	require.NoError(t, os.WriteFile(filename, b.Bytes(), 0o644))
+	return filename
+}
+
+func TestReaderLpHeader(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	interest, _ := tlv.EncodeFrom(ndn.MakeInterest("/A"))
+	fixed64 := func(typ uint32, n uint64) tlv.Field {
+		return tlv.TLVBytes(typ, binary.BigEndian.AppendUint64(nil, n))
+	}
+	lpp := tlv.TLV(an.TtLpPacket,
+		fixed64(an.TtLpSeqNum, 0xA0A1A2A3A4A5A6A7),
+		tlv.TLVNNI(an.TtFragIndex, 0),
+		tlv.TLVNNI(an.TtFragCount, 1),
+		tlv.TLVBytes(an.TtPitToken, []byte{0xB0, 0xB1, 0xB2, 0xB3}),
+		tlv.TLVNNI(0x0330, 7),                  // NextHopFaceId
+		tlv.TLVNNI(0x032C, 8),                  // IncomingFaceId
+		tlv.TLV(0x0334, tlv.TLVNNI(0x0335, 1)), // CachePolicy
+		tlv.TLVNNI(an.TtCongestionMark, 1),
+		fixed64(0x0344, 1000), // Ack
+		fixed64(0x0344, 1001), // Ack
+		fixed64(0x0348, 2000), // TxSequence
+		tlv.TLVBytes(an.TtLpPayload, interest),
+	)
+	wire, e := tlv.Encode(lpp)
+	require.NoError(e)
+	frame := append(makeSLL(layers.LinuxSLLPacketTypeHost, nil), wire...)
+
+	input, e := pcapinput.Open("", writeNdndpdkTrace(t, frame), nil)
+	require.NoError(e)
+	defer input.Close()
+
+	records := readAllRecords(t, input, ndntdump.ReaderOptions{})
+	require.Len(records, 1)
+	rec := records[0]
+	assert.Equal(">I", rec.DirType)
+	assert.Equal(uint64(0xA0A1A2A3A4A5A6A7), rec.LpSeq)
+	assert.Equal(0, rec.FragIndex)
+	assert.Equal(1, rec.FragCount)
+	assert.Equal([]byte{0xB0, 0xB1, 0xB2, 0xB3}, rec.PitToken)
+	assert.Equal(1, rec.CongMark)
+	assert.Equal(uint64(2000), rec.TxSeq)
+	assert.Equal([]uint64{1000, 1001}, rec.Acks)
+	assert.Equal(7, rec.NextHopFace)
+	assert.Equal(8, rec.IncomingFace)
+	assert.Equal(1, rec.CachePolicy)
+	assert.Equal(len(interest), rec.Size3)
+}
+
+func TestReaderSignedInterest(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	interest := ndn.MakeInterest("/A", ndn.NonceFromUint(0xC0C1C2C3), []byte{0xD0, 0xD1, 0xD2})
+	interest.SigInfo = &ndn.SigInfo{
+		Nonce:  []byte{0xE0, 0xE1},
+		Time:   1700000000000,
+		SeqNum: 5,
+	}
+	require.NoError(interest.SignWith(func(name ndn.Name, si *ndn.SigInfo) (ndn.LLSign, error) {
+		si.Type = an.SigHmacWithSha256
+		si.KeyLocator = ndn.KeyLocator{Name: ndn.ParseName("/K")}
+		return func(input []byte) ([]byte, error) { return make([]byte, 32), nil }, nil
+	}))
+	digest := interest.Name[len(interest.Name)-1].Value
+
+	input, e := pcapinput.Open("", writeNdndpdkTrace(t,
+		makeSLL(layers.LinuxSLLPacketTypeHost, interest),
+		makeSLL(layers.LinuxSLLPacketTypeHost, ndn.MakeInterest("/B")),
+	), nil)
+	require.NoError(e)
+	defer input.Close()
+
+	records := readAllRecords(t, input, ndntdump.ReaderOptions{})
+	require.Len(records, 2)
+
+	rec := records[0]
+	assert.Equal([]byte{0xC0, 0xC1, 0xC2, 0xC3}, rec.Nonce)
+	assert.True(rec.AppParams)
+	assert.Equal(3, rec.AppParamsLen)
+	assert.Equal(digest, rec.ParamsDigest)
+	assert.Equal("HMAC", rec.SigType)
+	assert.Equal("/8=K", rec.KeyLocator.String())
+	assert.Equal([]byte{0xE0, 0xE1}, rec.SigNonce)
+	assert.Equal(int64(1700000000000), rec.SigTime)
+	assert.Equal(uint64(5), rec.SigSeqNum)
+
+	rec = records[1]
+	assert.Len(rec.Nonce, 4)
+	assert.False(rec.AppParams)
+	assert.Nil(rec.ParamsDigest)
+	assert.Empty(rec.SigType)
+}
+
+func TestReaderDataSignature(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	data := ndn.MakeData("/A", []byte{0xC0, 0xC1, 0xC2, 0xC3, 0xC4})
+	require.NoError(data.SignWith(func(name ndn.Name, si *ndn.SigInfo) (ndn.LLSign, error) {
+		si.Type = an.SigSha256WithEcdsa
+		si.KeyLocator = ndn.KeyLocator{Digest: []byte{0xD0, 0xD1, 0xD2, 0xD3}}
+		return func(input []byte) ([]byte, error) { return make([]byte, 70), nil }, nil
+	}))
+	wire, e := tlv.EncodeFrom(data)
+	require.NoError(e)
+	digest := sha256.Sum256(wire)
+
+	input, e := pcapinput.Open("", writeNdndpdkTrace(t, makeSLL(layers.LinuxSLLPacketTypeHost, data)), nil)
+	require.NoError(e)
+	defer input.Close()
+
+	records := readAllRecords(t, input, ndntdump.ReaderOptions{})
+	require.Len(records, 1)
+	rec := records[0]
+	assert.Equal(">D", rec.DirType)
+	assert.Equal(5, rec.ContentLen)
+	assert.Equal("ECDSA", rec.SigType)
+	assert.Empty(rec.KeyLocator)
+	assert.Equal([]byte{0xD0, 0xD1, 0xD2, 0xD3}, rec.KeyDigest)
+	assert.Equal(70, rec.SigValueLen)
+	assert.Equal(digest[:], rec.Digest)
+	assert.NotContains(string(rec.Wire), string([]byte{0xC0, 0xC1, 0xC2, 0xC3, 0xC4}))
+}
+
+func TestReaderClassifier(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	interest := ndn.MakeInterest("/A", []byte{0xC0, 0xC1})
+	interest.UpdateParamsDigest()
+	input, e := pcapinput.Open("", writeNdndpdkTrace(t,
+		makeSLL(layers.LinuxSLLPacketTypeHost, interest),
+		makeSLL(layers.LinuxSLLPacketTypeHost, ndn.MakeData("/B", []byte{0xD0})),
+	), nil)
+	require.NoError(e)
+	defer input.Close()
+
+	var payloads [][]byte
+	records := readAllRecords(t, input, ndntdump.ReaderOptions{
+		Classifiers: []ndntdump.Classifier{
+			func(rec *ndntdump.Record, pkt *ndn.Packet) bool {
+				if pkt.Interest != nil {
+					payloads = append(payloads, bytes.Clone(pkt.Interest.AppParameters))
+					rec.Proto = "p0"
+					return true
+				}
+				return false
+			},
+			func(rec *ndntdump.Record, pkt *ndn.Packet) bool {
+				payloads = append(payloads, bytes.Clone(pkt.Data.Content))
+				rec.Proto = "p1"
+				return true
+			},
+		},
+	})
+	require.Len(records, 2)
+	assert.Equal("p0", records[0].Proto)
+	assert.Equal("p1", records[1].Proto)
+	assert.Equal([][]byte{{0xC0, 0xC1}, {0xD0}}, payloads)
+}
+
+func TestReaderSnapLen(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	paramsInterest := ndn.MakeInterest("/A", []byte{0xD0, 0xD1, 0xD2})
+	paramsInterest.UpdateParamsDigest()
+	interest, e := tlv.EncodeFrom(paramsInterest)
+	require.NoError(e)
+	data, e := tlv.EncodeFrom(ndn.MakeData("/A", bytes.Repeat([]byte{0xC0}, 100)))
+	require.NoError(e)
+	dataContent := bytes.Index(data, []byte{an.TtContent, 100, 0xC0}) + 2
+	require.Greater(dataContent, 2)
+
+	makeFragment := func(fragIndex int, payload []byte) []byte {
+		wire, e := tlv.Encode(tlv.TLV(an.TtLpPacket,
+			tlv.TLVNNI(an.TtFragIndex, fragIndex),
+			tlv.TLVNNI(an.TtFragCount, 2),
+			tlv.TLVBytes(an.TtLpPayload, payload),
+		))
+		require.NoError(e)
+		return append(makeSLL(layers.LinuxSLLPacketTypeHost, nil), wire...)
+	}
+	const sllLen, fragHeaderLen = 16, 2 + 3 + 3 + 2
+	frames := [][]byte{
+		makeSLL(layers.LinuxSLLPacketTypeHost, ndn.MakeInterest("/A")),
+		append(makeSLL(layers.LinuxSLLPacketTypeHost, nil), interest...),
+		append(makeSLL(layers.LinuxSLLPacketTypeHost, nil), data...),
+		makeFragment(0, data[:dataContent+50]),
+		makeFragment(1, data[dataContent+50:]),
+	}
+
+	input, e := pcapinput.Open("", writeNdndpdkTrace(t, frames...), nil)
+	require.NoError(e)
+	defer input.Close()
+
+	records := readAllRecords(t, input, ndntdump.ReaderOptions{KeepPayload: true})
+	require.Len(records, 5)
+	assert.Equal(len(frames[0]), records[0].SnapLen)
+	assert.Equal(len(frames[1])-3, records[1].SnapLen)
+	assert.Equal(sllLen+dataContent, records[2].SnapLen)
+	assert.Equal(sllLen+fragHeaderLen+dataContent, records[3].SnapLen)
+	assert.Equal(sllLen+fragHeaderLen, records[4].SnapLen)
+
+	filename := filepath.Join(t.TempDir(), "snap.pcapng")
+	o, e := fileoutput.NewPcapngOutput(filename, fileoutput.PcapngOptions{Snap: true})
+	require.NoError(e)
+	for _, rec := range records {
+		require.NoError(o.Write(rec))
+	}
+	require.NoError(o.Close())
+
+	f, e := os.Open(filename)
+	require.NoError(e)
+	defer f.Close()
+	r, e := pcapgo.NewNgReader(f, pcapgo.NgReaderOptions{WantMixedLinkType: true})
+	require.NoError(e)
+	for i, rec := range records {
+		wire, ci, e := r.ReadPacketData()
+		require.NoError(e)
+		assert.Equal(rec.Wire[:rec.SnapLen], wire, i)
+		assert.Equal(rec.SnapLen, ci.CaptureLength, i)
+		assert.Equal(len(frames[i]), ci.Length, i)
+	}
+}
@@ -1,10 +1,17 @@
 package ndntdump
 
 import (
+	"bytes"
+	"encoding/binary"
 	"io"
+	"math"
+	"strings"
 
 	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
 	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+	"github.com/usnistgov/ndn-dpdk/ndn/tlv"
 )
 
 // Direction indicates traffic direction.
@@ -27,27 +34,142 @@ const (
 	PktTypeNack     PktType = "N"
 )
 
+// NDNLPv2 TLV-TYPE numbers not defined in package an.
+const (
+	ttIncomingFaceID  = 0x032C
+	ttNextHopFaceID   = 0x0330
+	ttCachePolicy     = 0x0334
+	ttCachePolicyType = 0x0335
+	ttAck             = 0x0344
+	ttTxSequence      = 0x0348
+)
+
+func decodeFixed64(value []byte) (n uint64, ok bool) {
+	if len(value) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(value), true
+}
+
 // Record describes a parsed NDN packet.
 type Record struct {
 	Wire        []byte               `json:"-"`
 	CaptureInfo gopacket.CaptureInfo `json:"-"`
+	LinkType    layers.LinkType      `json:"-"`
+	SnapLen     int                  `json:"-"` // Wire length up to NDN name and metadata, zero if unknown
+
+	DirType   string   `json:"t"`                  // packet direction and type, or "!" for diagnostic record
+	Timestamp int64    `json:"ts"`                 // Unix epoch nanoseconds
+	Face      int      `json:"face,omitempty"`     // NDN-DPDK face ID
+	Flow      []byte   `json:"flow"`               // flow key
+	FlowID    string   `json:"flowId,omitempty"`   // flow key hash
+	FlowInfo  *FlowKey `json:"flowInfo,omitempty"` // structured flow key
+	Size2     int      `json:"size2"`              // packet size at NDNLPv2 layer
+
+	Diag      DiagReason `json:"diag,omitempty"`      // diagnostic reason
+	DiagError string     `json:"diagError,omitempty"` // diagnostic error message
+
+	LpSeq        uint64   `json:"lpSeq,omitempty"`        // NDNLPv2 Sequence
+	FragIndex    int      `json:"fragIndex,omitempty"`    // NDNLPv2 FragIndex
+	FragCount    int      `json:"fragCount,omitempty"`    // NDNLPv2 FragCount
+	PitToken     []byte   `json:"pitToken,omitempty"`     // NDNLPv2 PitToken
+	CongMark     int      `json:"congMark,omitempty"`     // NDNLPv2 CongestionMark
+	TxSeq        uint64   `json:"txSeq,omitempty"`        // NDNLPv2 TxSequence
+	Acks         []uint64 `json:"acks,omitempty"`         // NDNLPv2 Ack
+	NextHopFace  int      `json:"nextHopFace,omitempty"`  // NDNLPv2 NextHopFaceId
+	IncomingFace int      `json:"incomingFace,omitempty"` // NDNLPv2 IncomingFaceId
+	CachePolicy  int      `json:"cachePolicy,omitempty"`  // NDNLPv2 CachePolicyType
+
+	Size3        int        `json:"size3,omitempty"`        // packet size at L3
+	NackReason   int        `json:"nackReason,omitempty"`   // Nack reason
+	Name         ndn.Name   `json:"name,omitempty"`         // packet name
+	NameLen      int        `json:"nameLen,omitempty"`      // name length in components
+	NameSize     int        `json:"nameSize,omitempty"`     // name TLV-LENGTH in octets
+	NameComps    []NameComp `json:"nameComps,omitempty"`    // structured name components
+	Prefix       ndn.Name   `json:"prefix,omitempty"`       // name truncated for aggregation
+	CanBePrefix  bool       `json:"cbp,omitempty"`          // Interest CanBePrefix
+	MustBeFresh  bool       `json:"mbf,omitempty"`          // Interest MustBeFresh
+	FwHint       []ndn.Name `json:"fwHint,omitempty"`       // Interest ForwardingHint
+	Lifetime     int        `json:"lifetime,omitempty"`     // Interest InterestLifetime (ms)
+	HopLimit     int        `json:"hopLimit,omitempty"`     // Interest HopLimit
+	Nonce        []byte     `json:"nonce,omitempty"`        // Interest Nonce
+	AppParams    bool       `json:"appParams,omitempty"`    // Interest has ApplicationParameters
+	AppParamsLen int        `json:"appParamsLen,omitempty"` // Interest ApplicationParameters length
+	ParamsDigest []byte     `json:"paramsDigest,omitempty"` // Interest ParametersSha256DigestComponent
+	SigNonce     []byte     `json:"sigNonce,omitempty"`     // Interest SignatureNonce
+	SigTime      int64      `json:"sigTime,omitempty"`      // Interest SignatureTime (Unix epoch ms)
+	SigSeqNum    uint64     `json:"sigSeqNum,omitempty"`    // Interest SignatureSeqNum
+	SigType      string     `json:"sigType,omitempty"`      // Interest/Data SignatureType
+	KeyLocator   ndn.Name   `json:"keyLocator,omitempty"`   // Interest/Data KeyLocator name
+	KeyDigest    []byte     `json:"keyDigest,omitempty"`    // Interest/Data KeyLocator digest
+	SigValueLen  int        `json:"sigValueLen,omitempty"`  // Interest/Data SignatureValue length
+	ContentType  int        `json:"contentType,omitempty"`  // Data ContentType
+	Freshness    int        `json:"freshness,omitempty"`    // Data FreshnessPeriod (ms)
+	FinalBlock   bool       `json:"finalBlock,omitempty"`   // Data is final block
+	ContentLen   int        `json:"contentLen,omitempty"`   // Data Content length
+	Digest       []byte     `json:"digest,omitempty"`       // Data implicit digest
 
-	DirType   string `json:"t"`     // packet direction and type
-	Timestamp int64  `json:"ts"`    // Unix epoch nanoseconds
-	Flow      []byte `json:"flow"`  // flow key
-	Size2     int    `json:"size2"` // packet size at NDNLPv2 layer
-
-	Size3       int        `json:"size3,omitempty"`       // packet size at L3
-	NackReason  int        `json:"nackReason,omitempty"`  // Nack reason
-	Name        ndn.Name   `json:"name,omitempty"`        // packet name
-	CanBePrefix bool       `json:"cbp,omitempty"`         // Interest CanBePrefix
-	MustBeFresh bool       `json:"mbf,omitempty"`         // Interest MustBeFresh
-	FwHint      []ndn.Name `json:"fwHint,omitempty"`      // Interest ForwardingHint
-	Lifetime    int        `json:"lifetime,omitempty"`    // Interest InterestLifetime (ms)
-	HopLimit    int        `json:"hopLimit,omitempty"`    // Interest HopLimit
-	ContentType int        `json:"contentType,omitempty"` // Data ContentType
-	Freshness   int        `json:"freshness,omitempty"`   // Data FreshnessPeriod (ms)
-	FinalBlock  bool       `json:"finalBlock,omitempty"`  // Data is final block
+	Proto         string             `json:"proto,omitempty"`     // application protocol, set by Classifier
+	ProtoOp       string             `json:"protoOp,omitempty"`   // application protocol operation
+	ControlParams *ControlParameters `json:"cp,omitempty"`        // NFD management ControlParameters
+	SyncGroup     ndn.Name           `json:"syncGroup,omitempty"` // sync group prefix
+	StateVector   StateVector        `json:"sv,omitempty"`        // SVS state vector
+	IBFSize       int                `json:"ibfSize,omitempty"`   // PSync IBF name component length
+}
+
+// Direction returns the traffic direction of an NDN packet record.
+// It returns empty string for header and diagnostic records.
+func (rec *Record) Direction() Direction {
+	if len(rec.DirType) < 2 {
+		return ""
+	}
+	return Direction(rec.DirType[:1])
+}
+
+// HasPktType determines whether an NDN packet record has a packet type.
+// A first fragment has both PktTypeFragment and the type of its network layer packet, such as ">FI".
+func (rec *Record) HasPktType(t PktType) bool {
+	return len(rec.DirType) >= 2 && strings.Contains(rec.DirType[1:], string(t))
+}
+
+// SaveLpHeader saves NDNLPv2 header fields on this Record.
+// lpValue is the TLV-VALUE of LpPacket; unrecognized and malformed fields are skipped.
+func (rec *Record) SaveLpHeader(lpValue []byte) {
+	d := tlv.DecodingBuffer(lpValue)
+	for de := range d.IterElements() {
+		var e error
+		switch de.Type {
+		case an.TtLpSeqNum:
+			rec.LpSeq, _ = decodeFixed64(de.Value)
+		case an.TtFragIndex:
+			rec.FragIndex = int(de.UnmarshalNNI(math.MaxInt32, &e, tlv.ErrRange))
+		case an.TtFragCount:
+			rec.FragCount = int(de.UnmarshalNNI(math.MaxInt32, &e, tlv.ErrRange))
+		case an.TtPitToken:
+			rec.PitToken = bytes.Clone(de.Value)
+		case an.TtCongestionMark:
+			rec.CongMark = int(de.UnmarshalNNI(math.MaxInt32, &e, tlv.ErrRange))
+		case ttTxSequence:
+			rec.TxSeq, _ = decodeFixed64(de.Value)
+		case ttAck:
+			if ack, ok := decodeFixed64(de.Value); ok {
+				rec.Acks = append(rec.Acks, ack)
+			}
+		case ttNextHopFaceID:
+			rec.NextHopFace = int(de.UnmarshalNNI(math.MaxInt32, &e, tlv.ErrRange))
+		case ttIncomingFaceID:
+			rec.IncomingFace = int(de.UnmarshalNNI(math.MaxInt32, &e, tlv.ErrRange))
+		case ttCachePolicy:
+			d1 := tlv.DecodingBuffer(de.Value)
+			for de1 := range d1.IterElements() {
+				if de1.Type == ttCachePolicyType {
+					rec.CachePolicy = int(de1.UnmarshalNNI(math.MaxInt32, &e, tlv.ErrRange))
+				}
+			}
+		case an.TtLpPayload:
+			return
+		}
+	}
 }
 
 // SaveInterest saves Interest/Nack fields on this Record.
@@ -59,14 +181,46 @@ func (rec *Record) SaveInterest(interest ndn.Interest, nackReason uint8) {
 	rec.Lifetime = int(interest.Lifetime.Milliseconds())
 	rec.HopLimit = int(interest.HopLimit)
 	rec.NackReason = int(nackReason)
+	if !interest.Nonce.IsZero() {
+		rec.Nonce = interest.Nonce[:]
+	}
+	if interest.AppParameters != nil {
+		rec.AppParams = true
+		rec.AppParamsLen = len(interest.AppParameters)
+	}
+	if len(interest.Name) > 0 {
+		if comp := interest.Name[len(interest.Name)-1]; comp.Type == an.TtParametersSha256DigestComponent {
+			rec.ParamsDigest = bytes.Clone(comp.Value)
+		}
+	}
+	if si := interest.SigInfo; si != nil {
+		rec.saveSigInfo(si)
+		rec.SigNonce = bytes.Clone(si.Nonce)
+		rec.SigTime = int64(si.Time)
+		rec.SigSeqNum = si.SeqNum
+		rec.SigValueLen = len(interest.SigValue)
+	}
+}
+
+func (rec *Record) saveSigInfo(si *ndn.SigInfo) {
+	rec.SigType = an.SigTypeString(si.Type)
+	rec.KeyLocator = si.KeyLocator.Name
+	rec.KeyDigest = bytes.Clone(si.KeyLocator.Digest)
 }
 
 // SaveData saves Data fields on this Record.
+// It must be invoked before payload zeroization, so that the implicit digest reflects the original packet.
 func (rec *Record) SaveData(data ndn.Data) {
 	rec.Name = data.Name
 	rec.ContentType = int(data.ContentType)
 	rec.Freshness = int(data.Freshness.Milliseconds())
 	rec.FinalBlock = data.IsFinalBlock()
+	rec.ContentLen = len(data.Content)
+	rec.Digest = data.ComputeDigest()
+	if si := data.SigInfo; si != nil {
+		rec.saveSigInfo(si)
+		rec.SigValueLen = len(data.SigValue)
+	}
 }
 
 // RecordOutput represents an output stream.
@@ -0,0 +1,140 @@
+// Package recordinput reads records files written by ndntdump.
+package recordinput
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"iter"
+	"os"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/usnistgov/ndntdump"
+)
+
+// File reads records from a records file.
+// The file may be NDJSON or CBOR, and may be compressed with gzip or Zstandard, which are detected from magic bytes.
+type File struct {
+	file       *os.File
+	decompress io.Closer
+	dec        *ndntdump.RecordDecoder
+}
+
+// Header returns the records file header.
+// See ndntdump.RecordDecoder.Header.
+func (f *File) Header() ndntdump.Header {
+	return f.dec.Header()
+}
+
+// Read reads the next record.
+// Returns io.EOF at the end of file.
+//
+// CaptureInfo.Timestamp is restored from the record timestamp.
+// Other fields that are not saved in the records file are left empty.
+func (f *File) Read() (rec ndntdump.Record, e error) {
+	if rec, e = f.dec.Decode(); e != nil {
+		return
+	}
+	rec.CaptureInfo.Timestamp = time.Unix(0, rec.Timestamp)
+	return
+}
+
+// All returns an iterator over remaining records.
+// The iteration stops after yielding an error.
+// Use Record.FlowKey to decode the flow key of each record.
+func (f *File) All() iter.Seq2[ndntdump.Record, error] {
+	return func(yield func(ndntdump.Record, error) bool) {
+		for {
+			rec, e := f.Read()
+			switch {
+			case e == io.EOF:
+				return
+			case e != nil:
+				yield(rec, e)
+				return
+			case !yield(rec, nil):
+				return
+			}
+		}
+	}
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	errs := []error{}
+	if f.decompress != nil {
+		errs = append(errs, f.decompress.Close())
+	}
+	if f.file != nil {
+		errs = append(errs, f.file.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// NewReader creates File from a stream.
+func NewReader(r io.Reader) (f *File, e error) {
+	f = &File{}
+	if e = f.init(r); e != nil {
+		f.Close()
+		return nil, e
+	}
+	return f, nil
+}
+
+func (f *File) init(r io.Reader) (e error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	input := io.Reader(br)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1F, 0x8B}):
+		gz, e := gzip.NewReader(br)
+		if e != nil {
+			return e
+		}
+		input, f.decompress = gz, gz
+	case bytes.Equal(magic, []byte{0x28, 0xB5, 0x2F, 0xFD}):
+		zr, e := zstd.NewReader(br)
+		if e != nil {
+			return e
+		}
+		input, f.decompress = zr, zr.IOReadCloser()
+	}
+
+	f.dec, e = ndntdump.NewRecordDecoder(input)
+	return e
+}
+
+// Open opens a records file.
+func Open(filename string) (f *File, e error) {
+	f = &File{}
+	if f.file, e = os.Open(filename); e != nil {
+		return nil, e
+	}
+	if e = f.init(f.file); e != nil {
+		f.Close()
+		return nil, e
+	}
+	return f, nil
+}
+
+// Records returns an iterator over records in a file.
+// The file is closed when the iteration stops.
+func Records(filename string) iter.Seq2[ndntdump.Record, error] {
+	return func(yield func(ndntdump.Record, error) bool) {
+		f, e := Open(filename)
+		if e != nil {
+			yield(ndntdump.Record{}, e)
+			return
+		}
+		defer f.Close()
+
+		for rec, e := range f.All() {
+			if !yield(rec, e) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package recordinput_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/fileoutput"
+	"github.com/usnistgov/ndntdump/recordinput"
+)
+
+func TestRecords(t *testing.T) {
+	dir := t.TempDir()
+	for _, ext := range []string{".json", ".json.gz", ".json.zst", ".cbor", ".cbor.gz"} {
+		t.Run(ext, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+
+			filename := filepath.Join(dir, "records"+ext)
+			o, e := fileoutput.Open(filename, "", fileoutput.Options{Header: ndntdump.Header{Tool: "test"}})
+			require.NoError(e)
+			require.NoError(o.Write(ndntdump.Record{
+				DirType:   ">I",
+				Timestamp: 1700000000_000000001,
+				Flow:      []byte{192, 0, 2, 1, 192, 0, 2, 2, 17, 0x18, 0xDB, 0x18, 0xDC},
+				Size2:     10,
+				Name:      ndn.ParseName("/A"),
+				FwHint:    []ndn.Name{ndn.ParseName("/F")},
+			}))
+			require.NoError(o.Write(ndntdump.Record{
+				DirType:   "<D",
+				Timestamp: 1700000000_000000002,
+				Flow:      []byte{2, 0, 0, 0, 0, 1, 2, 0, 0, 0, 0, 2},
+				Size2:     20,
+			}))
+			require.NoError(o.Close())
+
+			f, e := recordinput.Open(filename)
+			require.NoError(e)
+			defer f.Close()
+			assert.Equal(ndntdump.SchemaVersion, f.Header().Schema)
+			assert.Equal("test", f.Header().Tool)
+
+			var records []ndntdump.Record
+			for rec, e := range f.All() {
+				require.NoError(e)
+				records = append(records, rec)
+			}
+			require.Len(records, 2)
+
+			assert.Equal(">I", records[0].DirType)
+			assert.Equal(int64(1700000000_000000001), records[0].CaptureInfo.Timestamp.UnixNano())
+			assert.Equal("/8=A", records[0].Name.String())
+			require.Len(records[0].FwHint, 1)
+			assert.Equal("/8=F", records[0].FwHint[0].String())
+			fk, e := records[0].FlowKey()
+			require.NoError(e)
+			assert.Equal(ndntdump.FlowKey{
+				Transport:  ndntdump.TransportUDP,
+				LocalIP:    "192.0.2.1",
+				RemoteIP:   "192.0.2.2",
+				LocalPort:  6363,
+				RemotePort: 6364,
+			}, fk)
+
+			fk, e = records[1].FlowKey()
+			require.NoError(e)
+			assert.Equal(ndntdump.FlowKey{
+				Transport: ndntdump.TransportEther,
+				LocalMAC:  "02:00:00:00:00:01",
+				RemoteMAC: "02:00:00:00:00:02",
+			}, fk)
+
+			n := 0
+			for range recordinput.Records(filename) {
+				n++
+				break
+			}
+			assert.Equal(1, n)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Package sample selects a subset of NDN packets to reduce output volume.
+package sample
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/usnistgov/ndntdump"
+)
+
+// Sampling modes.
+const (
+	// ModeCount keeps every Nth packet, where N is the reciprocal of Rate.
+	ModeCount = "count"
+	// ModeRandom keeps each packet independently with probability Rate.
+	ModeRandom = "random"
+	// ModeFlow keeps all packets of a consistently hashed subset of flows.
+	// Packets without a flow key are sampled as in count mode.
+	ModeFlow = "flow"
+	// ModePrefix keeps all packets under a consistently hashed subset of name prefixes.
+	// Packets without a name are sampled as in flow mode.
+	ModePrefix = "prefix"
+)
+
+// Modes returns names of available sampling modes.
+func Modes() []string {
+	return []string{ModeCount, ModeRandom, ModeFlow, ModePrefix}
+}
+
+// Options contains Sampler options.
+type Options struct {
+	// Mode is the sampling mode.
+	Mode string
+
+	// Rate is the fraction of packets kept, between 0 (exclusive) and 1 (inclusive).
+	Rate float64
+
+	// PrefixLen is the number of name components hashed in prefix mode.
+	// Default is 1.
+	PrefixLen int
+
+	// Seed is mixed into the hash in flow and prefix modes, or seeds the generator in random mode.
+	// Capture points using the same seed select the same prefixes.
+	// Flow keys contain addresses anonymized with a per-run secret, so that flow selection
+	// is consistent only within a run.
+	// Zero in random mode selects a random seed.
+	Seed uint64
+}
+
+func (opts *Options) applyDefaults() error {
+	if !slices.Contains(Modes(), opts.Mode) {
+		return fmt.Errorf("unknown sampling mode %s", opts.Mode)
+	}
+	if !(opts.Rate > 0 && opts.Rate <= 1) {
+		return fmt.Errorf("sampling rate %g out of range (0,1]", opts.Rate)
+	}
+	if opts.PrefixLen <= 0 {
+		opts.PrefixLen = 1
+	}
+	return nil
+}
+
+// ParseOptions parses sampling options from MODE:RATE syntax.
+// RATE may be a fraction such as 0.01, or a reciprocal such as 1/100.
+func ParseOptions(s string) (opts Options, e error) {
+	mode, rate, ok := strings.Cut(s, ":")
+	if !ok {
+		return opts, fmt.Errorf("sampling %s must be MODE:RATE", s)
+	}
+	opts.Mode = mode
+	if denom, ok := strings.CutPrefix(rate, "1/"); ok {
+		n, e := strconv.ParseUint(denom, 10, 64)
+		if e != nil || n == 0 {
+			return opts, fmt.Errorf("invalid sampling rate %s", rate)
+		}
+		opts.Rate = 1 / float64(n)
+	} else if opts.Rate, e = strconv.ParseFloat(rate, 64); e != nil {
+		return opts, fmt.Errorf("invalid sampling rate %s", rate)
+	}
+	return opts, nil
+}
+
+// Sampler decides whether each record is kept.
+// NDN packet records, including fragments, are sampled; header and diagnostic records are always kept.
+// Sampler is not thread-safe.
+type Sampler struct {
+	info      ndntdump.SamplingInfo
+	keep      func(rec *ndntdump.Record) bool // nil if every record is kept
+	period    uint64
+	counter   uint64
+	threshold uint64
+	rng       *rand.Rand
+	buf       []byte
+}
+
+// Info returns sampling configuration, to be recorded in Header.
+// In count mode, Rate reflects the rounded period.
+func (s *Sampler) Info() *ndntdump.SamplingInfo {
+	if s == nil {
+		return nil
+	}
+	info := s.info
+	return &info
+}
+
+// Keep determines whether a record is kept.
+// A nil Sampler keeps every record.
+func (s *Sampler) Keep(rec *ndntdump.Record) bool {
+	if s == nil || s.keep == nil {
+		return true
+	}
+	switch rec.DirType {
+	case ndntdump.DirTypeDiag, ndntdump.DirTypeHeader:
+		return true
+	}
+	return s.keep(rec)
+}
+
+func (s *Sampler) keepCount(*ndntdump.Record) bool {
+	s.counter++
+	return s.counter%s.period == 1%s.period
+}
+
+func (s *Sampler) keepRandom(*ndntdump.Record) bool {
+	return s.rng.Uint64() < s.threshold
+}
+
+func (s *Sampler) keepFlow(rec *ndntdump.Record) bool {
+	if len(rec.Flow) == 0 {
+		return s.keepCount(rec)
+	}
+	return s.hash(rec.Flow) < s.threshold
+}
+
+func (s *Sampler) keepPrefix(rec *ndntdump.Record) bool {
+	if len(rec.Name) == 0 {
+		return s.keepFlow(rec)
+	}
+	s.buf = s.buf[:0]
+	for _, comp := range rec.Name[:min(len(rec.Name), s.info.PrefixLen)] {
+		s.buf = binary.AppendUvarint(s.buf, uint64(comp.Type))
+		s.buf = binary.AppendUvarint(s.buf, uint64(len(comp.Value)))
+		s.buf = append(s.buf, comp.Value...)
+	}
+	return s.hash(s.buf) < s.threshold
+}
+
+// hash computes a seeded hash, which is consistent across processes and machines.
+// It is FNV-1a followed by MurmurHash3 finalizer, so that high bits are well distributed even for short inputs.
+func (s *Sampler) hash(input []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(binary.BigEndian.AppendUint64(nil, s.info.Seed))
+	h.Write(input)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// New creates Sampler.
+func New(opts Options) (s *Sampler, e error) {
+	if e = opts.applyDefaults(); e != nil {
+		return nil, e
+	}
+
+	s = &Sampler{
+		info: ndntdump.SamplingInfo{
+			Mode: opts.Mode,
+			Rate: opts.Rate,
+			Seed: opts.Seed,
+		},
+	}
+	if opts.Rate == 1 {
+		return s, nil
+	}
+	s.threshold = uint64(opts.Rate * (1 << 64))
+
+	switch opts.Mode {
+	case ModeCount:
+		s.period = uint64(math.Round(1 / opts.Rate))
+		s.info.Rate, s.info.Seed = 1/float64(s.period), 0
+		s.keep = s.keepCount
+	case ModeRandom:
+		seed := opts.Seed
+		if seed == 0 {
+			seed = rand.Uint64()
+		}
+		s.rng = rand.New(rand.NewPCG(seed, seed))
+		s.keep = s.keepRandom
+	case ModeFlow:
+		s.period = uint64(math.Round(1 / opts.Rate))
+		s.keep = s.keepFlow
+	case ModePrefix:
+		s.period = uint64(math.Round(1 / opts.Rate))
+		s.info.PrefixLen = opts.PrefixLen
+		s.keep = s.keepPrefix
+	}
+	return s, nil
+}
+
+// NewOutput wraps a RecordOutput to write only records kept by the sampler.
+// If s is nil, output is returned unchanged.
+func NewOutput(s *Sampler, output ndntdump.RecordOutput) ndntdump.RecordOutput {
+	if s == nil {
+		return output
+	}
+	return sampledOutput{output, s}
+}
+
+type sampledOutput struct {
+	ndntdump.RecordOutput
+	s *Sampler
+}
+
+func (o sampledOutput) Write(rec ndntdump.Record) error {
+	if !o.s.Keep(&rec) {
+		return nil
+	}
+	return o.RecordOutput.Write(rec)
+}
@@ -0,0 +1,177 @@
+package sample_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndn-dpdk/ndn"
+	"github.com/usnistgov/ndntdump"
+	"github.com/usnistgov/ndntdump/fileoutput"
+	"github.com/usnistgov/ndntdump/sample"
+)
+
+func makeRecord(i int) ndntdump.Record {
+	return ndntdump.Record{
+		DirType: ">I",
+		Flow:    []byte{byte(i >> 8), byte(i), 0xF0},
+		Name:    ndn.ParseName(fmt.Sprintf("/P%d/%d", i%1000, i)),
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	opts, e := sample.ParseOptions("count:1/100")
+	require.NoError(e)
+	assert.Equal(sample.ModeCount, opts.Mode)
+	assert.InDelta(0.01, opts.Rate, 1e-9)
+
+	opts, e = sample.ParseOptions("flow:0.25")
+	require.NoError(e)
+	assert.Equal(sample.ModeFlow, opts.Mode)
+	assert.InDelta(0.25, opts.Rate, 1e-9)
+
+	for _, s := range []string{"count", "count:1/0", "count:x", "unknown:0.1", "random:0", "random:1.5"} {
+		opts, e := sample.ParseOptions(s)
+		if e == nil {
+			_, e = sample.New(opts)
+		}
+		assert.Error(e, s)
+	}
+}
+
+func TestCount(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	s, e := sample.New(sample.Options{Mode: sample.ModeCount, Rate: 0.1})
+	require.NoError(e)
+	kept := 0
+	for i := range 1000 {
+		rec := makeRecord(i)
+		if i%2 == 0 {
+			rec.DirType = ">FI" // first fragment
+		}
+		if s.Keep(&rec) {
+			kept++
+		}
+	}
+	assert.Equal(100, kept)
+
+	diag := ndntdump.Record{DirType: ndntdump.DirTypeDiag}
+	assert.True(s.Keep(&diag))
+	hdr := ndntdump.Record{DirType: ndntdump.DirTypeHeader}
+	assert.True(s.Keep(&hdr))
+
+	info := s.Info()
+	assert.Equal(sample.ModeCount, info.Mode)
+	assert.InDelta(0.1, info.Rate, 1e-9)
+}
+
+func TestRandom(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	run := func(seed uint64) (decisions []bool) {
+		s, e := sample.New(sample.Options{Mode: sample.ModeRandom, Rate: 0.2, Seed: seed})
+		require.NoError(e)
+		for i := range 10000 {
+			rec := makeRecord(i)
+			decisions = append(decisions, s.Keep(&rec))
+		}
+		return
+	}
+
+	d1 := run(1)
+	kept := 0
+	for _, keep := range d1 {
+		if keep {
+			kept++
+		}
+	}
+	assert.InDelta(2000, kept, 200)
+	assert.Equal(d1, run(1))
+	assert.NotEqual(d1, run(2))
+}
+
+func TestHash(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	for _, mode := range []string{sample.ModeFlow, sample.ModePrefix} {
+		s, e := sample.New(sample.Options{Mode: mode, Rate: 0.25, Seed: 7})
+		require.NoError(e)
+		s2, e := sample.New(sample.Options{Mode: mode, Rate: 0.25, Seed: 7})
+		require.NoError(e)
+
+		kept := 0
+		for i := range 10000 {
+			rec := makeRecord(i)
+			keep := s.Keep(&rec)
+			if keep {
+				kept++
+			}
+			assert.Equal(keep, s2.Keep(&rec), mode)
+
+			// the same flow or prefix has the same decision
+			other := makeRecord(i)
+			switch mode {
+			case sample.ModeFlow:
+				other.Name = ndn.ParseName("/other")
+			case sample.ModePrefix:
+				other.Flow = nil
+				other.Name = append(other.Name, ndn.ParseName("/suffix")...)
+			}
+			assert.Equal(keep, s.Keep(&other), mode)
+		}
+		assert.InDelta(2500, kept, 500, mode)
+
+		// records without flow key or name are sampled by count
+		kept = 0
+		for range 100 {
+			rec := ndntdump.Record{DirType: ">I"}
+			if s.Keep(&rec) {
+				kept++
+			}
+		}
+		assert.Equal(25, kept, mode)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	s, e := sample.New(sample.Options{Mode: sample.ModePrefix, Rate: 0.5, PrefixLen: 2, Seed: 9})
+	require.NoError(e)
+
+	filename := filepath.Join(t.TempDir(), "records.ndjson")
+	o, e := fileoutput.Open(filename, "", fileoutput.Options{Sampler: s})
+	require.NoError(e)
+	written := 0
+	for i := range 100 {
+		rec := makeRecord(i)
+		if s2, _ := sample.New(sample.Options{Mode: sample.ModePrefix, Rate: 0.5, PrefixLen: 2, Seed: 9}); s2.Keep(&rec) {
+			written++
+		}
+		require.NoError(o.Write(rec))
+	}
+	require.NoError(o.Close())
+
+	f, e := os.Open(filename)
+	require.NoError(e)
+	defer f.Close()
+	d, e := ndntdump.NewRecordDecoder(f)
+	require.NoError(e)
+	assert.Equal(&ndntdump.SamplingInfo{Mode: sample.ModePrefix, Rate: 0.5, PrefixLen: 2, Seed: 9}, d.Header().Sampling)
+	n := 0
+	for {
+		if _, e := d.Decode(); e != nil {
+			break
+		}
+		n++
+	}
+	assert.Equal(written, n)
+	assert.Greater(n, 0)
+	assert.Less(n, 100)
+}
@@ -0,0 +1,152 @@
+package ndntdump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+//go:generate go run ./internal/schemagen record.schema.json
+
+// SchemaVersion is the current version of records file format.
+//
+//	Version 1: records only, no header.
+//	Version 2: first line is a Header.
+const SchemaVersion = 2
+
+// DirTypeHeader is the DirType of a header line.
+const DirTypeHeader = "#"
+
+// Header is the first line of a records file.
+type Header struct {
+	Type        string         `json:"t"`                  // always "#"
+	Schema      int            `json:"schema"`             // schema version
+	Tool        string         `json:"tool"`               // program name and version
+	Start       time.Time      `json:"start"`              // file creation time
+	Options     map[string]any `json:"options,omitempty"`  // command line options
+	Anon        AnonymizerInfo `json:"anon"`               // anonymization mode
+	KeepPayload bool           `json:"keepPayload"`        // payload is not zeroized
+	Sampling    *SamplingInfo  `json:"sampling,omitempty"` // packet sampling, absent if every packet is written
+}
+
+// AnonymizerInfo describes anonymization mode.
+type AnonymizerInfo struct {
+	IPv4Bits int      `json:"ipv4Bits"`          // IPv4 address leading bits kept
+	IPv6Bits int      `json:"ipv6Bits"`          // IPv6 address leading bits kept
+	MACBits  int      `json:"macBits"`           // MAC address leading bits kept
+	KeepIPs  []string `json:"keepIPs,omitempty"` // IP prefixes not anonymized
+}
+
+// SamplingInfo describes packet sampling mode.
+// Packet counts computed from sampled records may be divided by Rate to estimate totals.
+type SamplingInfo struct {
+	Mode      string  `json:"mode"`                // count, random, flow, or prefix
+	Rate      float64 `json:"rate"`                // fraction of packets written
+	PrefixLen int     `json:"prefixLen,omitempty"` // name components hashed in prefix mode
+	Seed      uint64  `json:"seed,omitempty"`      // hash seed, or random generator seed
+}
+
+// RecordDecoder decodes a records file of any schema version, in either NDJSON or CBOR format.
+type RecordDecoder struct {
+	read      func() ([]byte, error)
+	unmarshal func([]byte, any) error
+	hdr       Header
+	next      []byte
+}
+
+// Header returns the most recent header.
+// Version 1 file has no header; a header containing only Schema=1 is returned.
+func (d *RecordDecoder) Header() Header {
+	return d.hdr
+}
+
+// Decode decodes the next record.
+// Header items, which may appear in the middle of concatenated files, are consumed and reflected in Header().
+// Returns io.EOF at the end of input.
+func (d *RecordDecoder) Decode() (rec Record, e error) {
+	for {
+		item := d.next
+		d.next = nil
+		if item == nil {
+			if item, e = d.read(); e != nil {
+				return Record{}, e
+			}
+		}
+
+		if rec, e = d.decodeItem(item); e != nil || rec.DirType != DirTypeHeader {
+			return rec, e
+		}
+	}
+}
+
+func (d *RecordDecoder) decodeItem(item []byte) (rec Record, e error) {
+	if e = d.unmarshal(item, &rec); e != nil {
+		return Record{}, e
+	}
+	if rec.DirType != DirTypeHeader {
+		// version 1 records are a subset of version 2 records
+		return rec, nil
+	}
+
+	var hdr Header
+	if e = d.unmarshal(item, &hdr); e != nil {
+		return Record{}, e
+	}
+	if hdr.Schema > SchemaVersion {
+		return Record{}, fmt.Errorf("unsupported schema version %d", hdr.Schema)
+	}
+	d.hdr = hdr
+	return rec, nil
+}
+
+func (d *RecordDecoder) initNdjson(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	d.unmarshal = json.Unmarshal
+	d.read = func() ([]byte, error) {
+		for scanner.Scan() {
+			if line := scanner.Bytes(); len(line) > 0 {
+				return line, nil
+			}
+		}
+		if e := scanner.Err(); e != nil {
+			return nil, e
+		}
+		return nil, io.EOF
+	}
+}
+
+// NewRecordDecoder creates RecordDecoder.
+// The format is detected from CborMagic.
+// If the input starts with a header, it is consumed and reflected in Header().
+func NewRecordDecoder(r io.Reader) (d *RecordDecoder, e error) {
+	d = &RecordDecoder{
+		hdr: Header{Schema: 1},
+	}
+
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(CborMagic)); bytes.Equal(magic, CborMagic) {
+		d.initCbor(br)
+	} else {
+		d.initNdjson(br)
+	}
+
+	item, e := d.read()
+	switch {
+	case e == io.EOF:
+		return d, nil
+	case e != nil:
+		return nil, e
+	}
+	rec, e := d.decodeItem(item)
+	if e != nil {
+		return nil, e
+	}
+	if rec.DirType != DirTypeHeader {
+		d.next = bytes.Clone(item)
+	}
+	return d, nil
+}
@@ -0,0 +1,55 @@
+package ndntdump_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndntdump"
+)
+
+func TestRecordDecoder(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	readAll := func(input string) (hdr []int, dirTypes []string) {
+		d, e := ndntdump.NewRecordDecoder(strings.NewReader(input))
+		require.NoError(e)
+		for {
+			rec, e := d.Decode()
+			if e == io.EOF {
+				return
+			}
+			require.NoError(e)
+			hdr = append(hdr, d.Header().Schema)
+			dirTypes = append(dirTypes, rec.DirType)
+		}
+	}
+
+	// version 1, no header
+	hdr, dirTypes := readAll(`{"t":">I","ts":1,"flow":null,"size2":10,"name":"/8=A","cbp":true}
+{"t":"<D","ts":2,"flow":null,"size2":20,"name":"/8=A"}
+`)
+	assert.Equal([]int{1, 1}, hdr)
+	assert.Equal([]string{">I", "<D"}, dirTypes)
+
+	// version 2, concatenated files
+	hdr, dirTypes = readAll(`{"t":"#","schema":2,"tool":"ndntdump","start":"2024-01-01T00:00:00Z","anon":{"ipv4Bits":24,"ipv6Bits":48,"macBits":24},"keepPayload":false}
+{"t":">I","ts":1,"flow":null,"size2":10,"name":"/8=A"}
+
+{"t":"#","schema":2,"tool":"ndntdump","start":"2024-01-01T01:00:00Z","anon":{"ipv4Bits":24,"ipv6Bits":48,"macBits":24},"keepPayload":false}
+{"t":"!","ts":2,"flow":null,"size2":0,"diag":"tlv"}
+`)
+	assert.Equal([]int{2, 2}, hdr)
+	assert.Equal([]string{">I", "!"}, dirTypes)
+
+	// empty file
+	hdr, dirTypes = readAll("")
+	assert.Empty(hdr)
+	assert.Empty(dirTypes)
+
+	// future version
+	_, e := ndntdump.NewRecordDecoder(strings.NewReader(`{"t":"#","schema":999}` + "\n"))
+	assert.Error(e)
+}
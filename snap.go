@@ -0,0 +1,73 @@
+package ndntdump
+
+import (
+	"github.com/usnistgov/ndn-dpdk/ndn/an"
+)
+
+// ndnSnapLen returns the number of octets in an NDN packet that contain name and metadata.
+// The packet is cut after TLV-TYPE and TLV-LENGTH of Interest ApplicationParameters, Data Content,
+// or the fragment of a non-first NDNLPv2 fragment.
+// Unrecognized and malformed packets are retained in full.
+func ndnSnapLen(wire []byte) int {
+	var ele incompleteTLV
+	if _, e := ele.Decode(wire); e != nil {
+		return len(wire)
+	}
+	offset := ele.Size - ele.Length
+
+	switch ele.Type {
+	case an.TtLpPacket:
+		firstFragment := true
+		for value := ele.Value; len(value) > 0; {
+			var child incompleteTLV
+			rest, e := child.Decode(value)
+			if e != nil {
+				break
+			}
+			switch child.Type {
+			case an.TtFragIndex:
+				for _, b := range child.Value {
+					if b != 0 {
+						firstFragment = false
+					}
+				}
+			case an.TtLpPayload:
+				offset += len(value) - len(child.Value) - len(rest)
+				if !firstFragment {
+					return offset
+				}
+				return min(len(wire), offset+ndnSnapLen(child.Value))
+			}
+			offset += len(value) - len(rest)
+			value = rest
+		}
+	case an.TtInterest, an.TtData:
+		payloadType := uint32(an.TtAppParameters)
+		if ele.Type == an.TtData {
+			payloadType = an.TtContent
+		}
+		for value := ele.Value; len(value) > 0; {
+			var child incompleteTLV
+			rest, e := child.Decode(value)
+			if e != nil {
+				break
+			}
+			if child.Type == payloadType {
+				return offset + len(value) - len(child.Value) - len(rest)
+			}
+			offset += len(value) - len(rest)
+			value = rest
+		}
+	}
+	return len(wire)
+}
+
+// snapLen returns the number of octets in frame up to name and metadata of the NDN packet ndnWire.
+// ndnWire must be a subslice of frame; otherwise, it returns zero.
+func snapLen(frame, ndnWire []byte) int {
+	offset := cap(frame) - cap(ndnWire)
+	if len(ndnWire) == 0 || offset < 0 || offset+len(ndnWire) > len(frame) || &frame[offset] != &ndnWire[0] {
+		return 0
+	}
+	return offset + ndnSnapLen(ndnWire)
+}
@@ -0,0 +1,76 @@
+package spool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// watcher reports files that are completely written into or moved into a directory.
+// An empty name indicates that some events were lost and the directory should be rescanned.
+type watcher struct {
+	file   *os.File
+	events chan string
+	done   chan struct{}
+}
+
+func (w *watcher) loop() {
+	defer close(w.events)
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, e := w.file.Read(buf)
+		if e != nil {
+			return
+		}
+
+		for b := buf[:n]; len(b) >= unix.SizeofInotifyEvent; {
+			mask := binary.NativeEndian.Uint32(b[4:])
+			nameLen := int(binary.NativeEndian.Uint32(b[12:]))
+			name := b[unix.SizeofInotifyEvent:min(unix.SizeofInotifyEvent+nameLen, len(b))]
+			b = b[min(unix.SizeofInotifyEvent+nameLen, len(b)):]
+
+			var filename string
+			switch {
+			case mask&unix.IN_Q_OVERFLOW != 0:
+			case mask&(unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO) != 0:
+				filename = string(bytes.TrimRight(name, "\x00"))
+			default:
+				continue
+			}
+
+			select {
+			case w.events <- filename:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// Close stops watching.
+func (w *watcher) Close() error {
+	close(w.done)
+	return w.file.Close()
+}
+
+func newWatcher(dir string) (w *watcher, e error) {
+	fd, e := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if e != nil {
+		return nil, os.NewSyscallError("inotify_init1", e)
+	}
+	if _, e = unix.InotifyAddWatch(fd, dir, unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO|unix.IN_ONLYDIR); e != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", e)
+	}
+
+	// non-blocking file descriptor is registered with the runtime poller, so that Close unblocks Read
+	w = &watcher{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan string, 64),
+		done:   make(chan struct{}),
+	}
+	go w.loop()
+	return w, nil
+}
@@ -0,0 +1,275 @@
+// Package spool processes trace files as they arrive in a directory.
+package spool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Status values in journal entries.
+const (
+	StatusDone   = "done"
+	StatusFailed = "failed"
+)
+
+// JournalEntry is a line in the journal file, which records the outcome of an input file.
+type JournalEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func (ent JournalEntry) matches(fi os.FileInfo) bool {
+	return ent.Size == fi.Size() && ent.ModTime.Equal(fi.ModTime())
+}
+
+// Config contains Spooler configuration.
+type Config struct {
+	// InputDir is the directory to be watched for incoming files.
+	// Files whose names start with "." are ignored.
+	InputDir string
+
+	// DoneDir receives input files that have been successfully processed.
+	DoneDir string
+
+	// FailedDir receives input files that cannot be processed.
+	FailedDir string
+
+	// JournalFile records processed input files, so that processing can resume after a restart.
+	// Default is ".journal.ndjson" in DoneDir.
+	JournalFile string
+
+	// Process processes an input file.
+	// It should abort when ctx is cancelled.
+	Process func(ctx context.Context, filename string) error
+
+	// QuietPeriod is the minimum time since last modification before a file found by a directory scan,
+	// at startup or after inotify events are lost, is processed.
+	// A recently modified file may still be written; it is processed upon its inotify event or a later scan.
+	// Default is 10 seconds.
+	QuietPeriod time.Duration
+
+	// MoveError is invoked when an input file cannot be moved to DoneDir or FailedDir.
+	// The journal entry is retained, so that the file is moved without reprocessing on a later attempt.
+	// nil ignores such errors.
+	MoveError func(filename string, e error)
+}
+
+// Spooler watches a directory and processes each incoming file.
+type Spooler struct {
+	cfg     Config
+	journal map[string]JournalEntry
+	jf      *os.File
+}
+
+// Run processes existing and incoming files until ctx is cancelled.
+func (s *Spooler) Run(ctx context.Context) (e error) {
+	// start watching before scanning the directory, so that no file is missed
+	w, e := newWatcher(s.cfg.InputDir)
+	if e != nil {
+		return e
+	}
+	defer w.Close()
+
+	rescan, e := s.scan(ctx)
+	if e != nil {
+		return e
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-rescan:
+			rescan, e = s.scan(ctx)
+		case name, ok := <-w.events:
+			switch {
+			case !ok:
+				return errors.New("inotify watcher stopped")
+			case name == "":
+				rescan, e = s.scan(ctx)
+			default:
+				e = s.handle(ctx, name)
+			}
+			if e != nil {
+				return e
+			}
+		}
+	}
+}
+
+// scan processes files in InputDir that have not been modified within QuietPeriod.
+// If any file is skipped, returns a channel that fires when the directory should be scanned again.
+func (s *Spooler) scan(ctx context.Context) (rescan <-chan time.Time, e error) {
+	entries, e := os.ReadDir(s.cfg.InputDir)
+	if e != nil {
+		return nil, e
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+		if fi, e := entry.Info(); e == nil && time.Since(fi.ModTime()) < s.cfg.QuietPeriod {
+			rescan = time.After(s.cfg.QuietPeriod)
+			continue
+		}
+		if e := s.handle(ctx, entry.Name()); e != nil {
+			return nil, e
+		}
+	}
+	return rescan, nil
+}
+
+// handle processes an input file and moves it to DoneDir or FailedDir.
+// Returns error only if the spooler cannot continue.
+func (s *Spooler) handle(ctx context.Context, name string) error {
+	filename := filepath.Join(s.cfg.InputDir, name)
+	fi, e := os.Stat(filename)
+	if strings.HasPrefix(name, ".") || e != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+
+	ent, ok := s.journal[name]
+	if !ok || !ent.matches(fi) {
+		ent = JournalEntry{
+			Name:    name,
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+			Status:  StatusDone,
+		}
+		if e := s.cfg.Process(ctx, filename); e != nil {
+			if ctx.Err() != nil {
+				// interrupted, leave the file in InputDir to be reprocessed after restart
+				return nil
+			}
+			ent.Status, ent.Error = StatusFailed, e.Error()
+		}
+		ent.Time = time.Now()
+		if e := s.record(ent); e != nil {
+			return e
+		}
+	}
+
+	dir := s.cfg.DoneDir
+	if ent.Status == StatusFailed {
+		dir = s.cfg.FailedDir
+	}
+	if e := moveFile(filename, filepath.Join(dir, name)); e != nil && s.cfg.MoveError != nil {
+		s.cfg.MoveError(filename, e)
+	}
+	return nil
+}
+
+// moveFile renames a file, or copies and deletes it if the destination is on another filesystem.
+func moveFile(src, dst string) error {
+	e := os.Rename(src, dst)
+	if !errors.Is(e, syscall.EXDEV) {
+		return e
+	}
+
+	r, e := os.Open(src)
+	if e != nil {
+		return e
+	}
+	defer r.Close()
+
+	dir, name := filepath.Split(dst)
+	tmp := filepath.Join(dir, "."+name)
+	w, e := os.Create(tmp)
+	if e != nil {
+		return e
+	}
+	_, e = io.Copy(w, r)
+	if e = errors.Join(e, w.Sync(), w.Close()); e == nil {
+		e = os.Rename(tmp, dst)
+	}
+	if e != nil {
+		os.Remove(tmp)
+		return e
+	}
+	return os.Remove(src)
+}
+
+func (s *Spooler) record(ent JournalEntry) error {
+	s.journal[ent.Name] = ent
+	line, _ := json.Marshal(ent)
+	if _, e := s.jf.Write(append(line, '\n')); e != nil {
+		return e
+	}
+	return s.jf.Sync()
+}
+
+// Close closes the journal file.
+func (s *Spooler) Close() error {
+	return s.jf.Close()
+}
+
+// New creates Spooler.
+func New(cfg Config) (s *Spooler, e error) {
+	if cfg.InputDir == "" || cfg.DoneDir == "" || cfg.FailedDir == "" || cfg.Process == nil {
+		return nil, errors.New("InputDir, DoneDir, FailedDir, Process are required")
+	}
+	if cfg.JournalFile == "" {
+		cfg.JournalFile = filepath.Join(cfg.DoneDir, ".journal.ndjson")
+	}
+	if cfg.QuietPeriod <= 0 {
+		cfg.QuietPeriod = 10 * time.Second
+	}
+	for _, dir := range []string{cfg.DoneDir, cfg.FailedDir} {
+		if e := os.MkdirAll(dir, 0o755); e != nil {
+			return nil, e
+		}
+	}
+
+	s = &Spooler{
+		cfg:     cfg,
+		journal: map[string]JournalEntry{},
+	}
+	if e = s.loadJournal(); e != nil {
+		return nil, e
+	}
+	if s.jf, e = os.OpenFile(cfg.JournalFile, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644); e != nil {
+		return nil, e
+	}
+
+	// terminate a line torn by a crash
+	if fi, e := s.jf.Stat(); e == nil && fi.Size() > 0 {
+		last := []byte{0}
+		if s.jf.ReadAt(last, fi.Size()-1); last[0] != '\n' {
+			s.jf.Write([]byte{'\n'})
+		}
+	}
+	return s, nil
+}
+
+func (s *Spooler) loadJournal() error {
+	f, e := os.Open(s.cfg.JournalFile)
+	if errors.Is(e, os.ErrNotExist) {
+		return nil
+	} else if e != nil {
+		return e
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ent JournalEntry
+		if e := json.Unmarshal(scanner.Bytes(), &ent); e != nil {
+			// a line may be torn by a crash; affected input file would be reprocessed
+			continue
+		}
+		s.journal[ent.Name] = ent
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,165 @@
+package spool_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/usnistgov/ndntdump/spool"
+)
+
+func TestSpooler(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	cfg := spool.Config{
+		InputDir:    filepath.Join(dir, "in"),
+		DoneDir:     filepath.Join(dir, "done"),
+		FailedDir:   filepath.Join(dir, "failed"),
+		QuietPeriod: 50 * time.Millisecond,
+	}
+	require.NoError(os.Mkdir(cfg.InputDir, 0o755))
+	require.NoError(os.WriteFile(filepath.Join(cfg.InputDir, "A.pcap"), []byte("good"), 0o644))
+
+	var mu sync.Mutex
+	processed := []string{}
+	cfg.Process = func(ctx context.Context, filename string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, filepath.Base(filename))
+		if strings.HasPrefix(filepath.Base(filename), "B") {
+			return errors.New("bad")
+		}
+		return nil
+	}
+
+	run := func(during func()) {
+		s, e := spool.New(cfg)
+		require.NoError(e)
+		defer s.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- s.Run(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+		during()
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+		assert.NoError(<-done)
+	}
+
+	run(func() {
+		require.NoError(os.WriteFile(filepath.Join(cfg.InputDir, "B.pcap"), []byte("bad"), 0o644))
+		require.NoError(os.WriteFile(filepath.Join(cfg.InputDir, ".C.pcap"), []byte("partial"), 0o644))
+		require.NoError(os.Rename(filepath.Join(cfg.InputDir, ".C.pcap"), filepath.Join(cfg.InputDir, "C.pcap")))
+	})
+	assert.Equal([]string{"A.pcap", "B.pcap", "C.pcap"}, processed)
+	assert.FileExists(filepath.Join(cfg.DoneDir, "A.pcap"))
+	assert.FileExists(filepath.Join(cfg.FailedDir, "B.pcap"))
+	assert.FileExists(filepath.Join(cfg.DoneDir, "C.pcap"))
+
+	// simulate a crash after journal is written but before the file is moved
+	require.NoError(os.Rename(filepath.Join(cfg.DoneDir, "A.pcap"), filepath.Join(cfg.InputDir, "A.pcap")))
+	processed = processed[:0]
+	run(func() {})
+	assert.Empty(processed)
+	assert.FileExists(filepath.Join(cfg.DoneDir, "A.pcap"))
+}
+
+func TestSpoolerMoveError(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	var moveErrors []string
+	cfg := spool.Config{
+		InputDir:    filepath.Join(dir, "in"),
+		DoneDir:     filepath.Join(dir, "done"),
+		FailedDir:   filepath.Join(dir, "failed"),
+		QuietPeriod: 50 * time.Millisecond,
+		Process: func(ctx context.Context, filename string) error {
+			if strings.HasPrefix(filepath.Base(filename), "A") {
+				// input file removed during processing
+				return os.Remove(filename)
+			}
+			return nil
+		},
+		MoveError: func(filename string, e error) {
+			moveErrors = append(moveErrors, filepath.Base(filename))
+		},
+	}
+	require.NoError(os.Mkdir(cfg.InputDir, 0o755))
+	require.NoError(os.WriteFile(filepath.Join(cfg.InputDir, "A.pcap"), []byte("A"), 0o644))
+	require.NoError(os.WriteFile(filepath.Join(cfg.InputDir, "B.pcap"), []byte("B"), 0o644))
+
+	s, e := spool.New(cfg)
+	require.NoError(e)
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.NoError(s.Run(ctx))
+	assert.Equal([]string{"A.pcap"}, moveErrors)
+	assert.FileExists(filepath.Join(cfg.DoneDir, "B.pcap"))
+}
+
+func TestSpoolerQuietPeriod(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	dir := t.TempDir()
+	var mu sync.Mutex
+	sizes := map[string]int64{}
+	cfg := spool.Config{
+		InputDir:    filepath.Join(dir, "in"),
+		DoneDir:     filepath.Join(dir, "done"),
+		FailedDir:   filepath.Join(dir, "failed"),
+		QuietPeriod: time.Second,
+		Process: func(ctx context.Context, filename string) error {
+			fi, e := os.Stat(filename)
+			mu.Lock()
+			defer mu.Unlock()
+			sizes[filepath.Base(filename)] = fi.Size()
+			return e
+		},
+	}
+	require.NoError(os.Mkdir(cfg.InputDir, 0o755))
+
+	// upload in progress when the spooler starts
+	f, e := os.Create(filepath.Join(cfg.InputDir, "A.pcap"))
+	require.NoError(e)
+	defer f.Close()
+	_, e = f.WriteString("partial")
+	require.NoError(e)
+
+	s, e := spool.New(cfg)
+	require.NoError(e)
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	time.Sleep(200 * time.Millisecond)
+	mu.Lock()
+	assert.Empty(sizes)
+	mu.Unlock()
+
+	// upload completes, which generates IN_CLOSE_WRITE
+	_, e = f.WriteString(" complete")
+	require.NoError(e)
+	require.NoError(f.Close())
+	require.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sizes) == 1
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.NoError(<-done)
+	assert.Equal(map[string]int64{"A.pcap": 16}, sizes)
+	assert.FileExists(filepath.Join(cfg.DoneDir, "A.pcap"))
+}
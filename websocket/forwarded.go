@@ -39,3 +39,8 @@ func AnonymizeXForwardedFor(p []byte) {
 		copy(room[headroom:], repl)
 	}
 }
+
+// IsHandshake determines whether TCP payload is an HTTP request or response, such as an UPGRADE handshake.
+func IsHandshake(p []byte) bool {
+	return bytes.HasPrefix(p, []byte("GET ")) || bytes.HasPrefix(p, []byte("HTTP/"))
+}
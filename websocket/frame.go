@@ -1,7 +1,9 @@
-// Package websocket parses WebSocket frames out of TCP payload.
+// Package websocket parses WebSocket frames out of TCP payload, and encodes frames for the live feed server.
 package websocket
 
 import (
+	"crypto/sha1"
+	"encoding/base64"
 	"encoding/binary"
 	"errors"
 )
@@ -11,7 +13,9 @@ var errTruncated = errors.New("truncated")
 // WebSocket flags and opcodes.
 const (
 	FlagFin  = 0x80
+	OpText   = 0x01
 	OpBinary = 0x02
+	OpClose  = 0x08
 )
 
 // Frame contains a WebSocket frame.
@@ -60,6 +64,24 @@ func (f *Frame) Decode(input []byte) (rest []byte, e error) {
 	return input[end:], nil
 }
 
+// Encode encodes an unmasked frame, as sent by a server.
+// MaskingKey is ignored.
+func (f Frame) Encode() []byte {
+	b := make([]byte, 0, 10+len(f.Payload))
+	b = append(b, f.FlagOp)
+	switch length := len(f.Payload); {
+	case length < 126:
+		b = append(b, byte(length))
+	case length <= 0xFFFF:
+		b = append(b, 126)
+		b = binary.BigEndian.AppendUint16(b, uint16(length))
+	default:
+		b = append(b, 127)
+		b = binary.BigEndian.AppendUint64(b, uint64(length))
+	}
+	return append(b, f.Payload...)
+}
+
 // Unmask changes MaskingKey to zero and reveals the payload.
 func (f *Frame) Unmask() {
 	if len(f.MaskingKey) == 0 || f.MaskingKey[0]|f.MaskingKey[1]|f.MaskingKey[2]|f.MaskingKey[3] == 0 {
@@ -91,3 +113,12 @@ func ExtractBinaryFrames(input []byte) (frames []Frame, e error) {
 	}
 	return
 }
+
+// handshakeGUID is appended to Sec-WebSocket-Key when computing Sec-WebSocket-Accept.
+const handshakeGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// AcceptKey computes Sec-WebSocket-Accept header value from Sec-WebSocket-Key header value.
+func AcceptKey(key string) string {
+	digest := sha1.Sum([]byte(key + handshakeGUID))
+	return base64.StdEncoding.EncodeToString(digest[:])
+}